@@ -0,0 +1,209 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"glox/lox"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// The bundled programs cover what Lox can express today. fib, binary_trees,
+// method_call and zoo from the classic suite need functions and classes.
+//
+//go:embed bench/*.lox
+var benchPrograms embed.FS
+
+type benchResult struct {
+	Name        string  `json:"name"`
+	Engine      string  `json:"engine"`
+	Samples     int     `json:"samples"`
+	Iterations  int     `json:"iterations_per_sample"`
+	MeanNs      float64 `json:"mean_ns"`
+	StddevNs    float64 `json:"stddev_ns"`
+	AllocsPerOp float64 `json:"allocs_per_op"`
+	BytesPerOp  float64 `json:"bytes_per_op"`
+}
+
+// bench implements glox bench, which times the bundled benchmark programs,
+// or the given files, on each execution engine.
+func bench(args []string) {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	engines := flags.String("engines", "tree,closure,vm", "comma-separated engines to measure")
+	samples := flags.Int("n", 10, "number of samples per program and engine")
+	sampleTime := flags.Duration("sample-time", 50*time.Millisecond, "minimum duration of each sample")
+	asJSON := flags.Bool("json", false, "write results as JSON")
+	flags.Parse(args)
+
+	sources, err := benchSources(flags.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var results []benchResult
+	for _, name := range sources.names {
+		expression := parseSource(sources.programs[name])
+
+		for _, engine := range strings.Split(*engines, ",") {
+			run, err := benchRunner(engine, expression)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+				os.Exit(70)
+			}
+
+			result := measure(run, max(*samples, 1), *sampleTime)
+			result.Name = name
+			result.Engine = engine
+			results = append(results, result)
+
+			if !*asJSON {
+				fmt.Printf("%-24s %-8s %12.0f ns/op ± %5.1f%% %8.1f allocs/op %10.1f B/op\n",
+					result.Name, result.Engine, result.MeanNs, 100*result.StddevNs/result.MeanNs,
+					result.AllocsPerOp, result.BytesPerOp)
+			}
+		}
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(results)
+	}
+}
+
+type benchSet struct {
+	names    []string
+	programs map[string]string
+}
+
+func benchSources(paths []string) (benchSet, error) {
+	set := benchSet{programs: map[string]string{}}
+
+	if len(paths) == 0 {
+		entries, err := fs.ReadDir(benchPrograms, "bench")
+		if err != nil {
+			return set, err
+		}
+		for _, entry := range entries {
+			source, err := fs.ReadFile(benchPrograms, "bench/"+entry.Name())
+			if err != nil {
+				return set, err
+			}
+			name := strings.TrimSuffix(entry.Name(), ".lox")
+			set.names = append(set.names, name)
+			set.programs[name] = string(source)
+		}
+		return set, nil
+	}
+
+	for _, file := range paths {
+		source, err := os.ReadFile(file)
+		if err != nil {
+			return set, err
+		}
+		name := strings.TrimSuffix(path.Base(file), ".lox")
+		set.names = append(set.names, name)
+		set.programs[name] = string(source)
+	}
+	return set, nil
+}
+
+// benchRunner prepares expression for engine and returns a function that
+// evaluates it once. Compilation happens here, outside the measurement.
+func benchRunner(engine string, expression lox.Expr) (func() error, error) {
+	var run func() error
+
+	switch engine {
+	case "tree":
+		run = func() error {
+			_, err := lox.Interpret(expression)
+			return err
+		}
+	case "closure":
+		eval := lox.CompileClosures(expression)
+		run = func() error {
+			_, err := eval()
+			return err
+		}
+	case "vm":
+		chunk, err := lox.Compile(expression)
+		if err != nil {
+			return nil, err
+		}
+		vm := lox.NewVM()
+		run = func() error {
+			_, err := vm.Run(chunk)
+			return err
+		}
+	default:
+		return nil, fmt.Errorf("unknown engine %q", engine)
+	}
+
+	return run, run()
+}
+
+func measure(run func() error, samples int, sampleTime time.Duration) benchResult {
+	iterations := 1
+	for {
+		start := time.Now()
+		for i := 0; i < iterations; i++ {
+			run()
+		}
+		if time.Since(start) >= sampleTime/10 {
+			iterations = int(float64(iterations) * float64(sampleTime) / float64(time.Since(start)))
+			break
+		}
+		iterations *= 10
+	}
+	iterations = max(iterations, 1)
+
+	var before, after runtime.MemStats
+	durations := make([]float64, samples)
+	var mallocs, bytes uint64
+
+	for sample := range durations {
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+		for i := 0; i < iterations; i++ {
+			run()
+		}
+		elapsed := time.Since(start)
+		runtime.ReadMemStats(&after)
+
+		durations[sample] = float64(elapsed.Nanoseconds()) / float64(iterations)
+		mallocs += after.Mallocs - before.Mallocs
+		bytes += after.TotalAlloc - before.TotalAlloc
+	}
+
+	mean := 0.0
+	for _, duration := range durations {
+		mean += duration
+	}
+	mean /= float64(samples)
+
+	variance := 0.0
+	for _, duration := range durations {
+		variance += (duration - mean) * (duration - mean)
+	}
+	if samples > 1 {
+		variance /= float64(samples - 1)
+	}
+
+	operations := float64(samples * iterations)
+	return benchResult{
+		Samples:     samples,
+		Iterations:  iterations,
+		MeanNs:      mean,
+		StddevNs:    math.Sqrt(variance),
+		AllocsPerOp: float64(mallocs) / operations,
+		BytesPerOp:  float64(bytes) / operations,
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"glox/lox"
+	"log"
+	"os"
+	"strings"
+)
+
+// compileFile implements glox compile, which writes the bytecode of a source
+// file to a .loxc file that glox can run directly.
+func compileFile(args []string) {
+	flags := flag.NewFlagSet("compile", flag.ExitOnError)
+	output := flags.String("o", "", "output file (default: input with a .loxc extension)")
+
+	// Allow the output flag after the input file, as in "compile in.lox -o out.loxc".
+	var inputs []string
+	for len(args) > 0 {
+		flags.Parse(args)
+		args = flags.Args()
+		if len(args) > 0 {
+			inputs = append(inputs, args[0])
+			args = args[1:]
+		}
+	}
+
+	if len(inputs) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: glox compile <file> [-o out.loxc]")
+		os.Exit(64)
+	}
+	input := inputs[0]
+	if *output == "" {
+		*output = strings.TrimSuffix(input, ".lox") + ".loxc"
+	}
+
+	chunk := compileExpression(parseSource(readSource(input)))
+	data, err := chunk.MarshalBinary()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(65)
+	}
+
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// disassembleFile implements glox disasm, which lists the bytecode of a
+// source or .loxc file.
+func disassembleFile(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: glox disasm <file>")
+		os.Exit(64)
+	}
+
+	loadChunk(args[0]).Disassemble(os.Stdout, args[0])
+}
+
+func compileExpression(expression lox.Expr) *lox.Chunk {
+	chunk, err := lox.Compile(expression)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(65)
+	}
+	return chunk
+}
+
+// loadChunk reads the chunk in a .loxc file, or compiles a source file.
+func loadChunk(path string) *lox.Chunk {
+	source := readSource(path)
+	if !lox.IsLoxc([]byte(source)) {
+		return compileExpression(parseSource(source))
+	}
+
+	var chunk lox.Chunk
+	if err := chunk.UnmarshalBinary([]byte(source)); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+		os.Exit(65)
+	}
+	return &chunk
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"glox/lox"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type stepMode int
+
+const (
+	modeContinue stepMode = iota
+	modeStep
+	modeNext
+	modeFinish
+)
+
+// debugger is a gdb-style front end driven by the interpreter's Hook.
+// Lox programs are single expressions for now, so a "frame" is an
+// expression still being evaluated and the backtrace is the chain of
+// enclosing expressions.
+type debugger struct {
+	path        string
+	input       *bufio.Scanner
+	output      io.Writer
+	breakpoints map[int]bool
+	stack       []lox.Expr
+	mode        stepMode
+	modeDepth   int
+	line        int
+	stoppedLine int
+}
+
+// quitting unwinds the interpreter when the user quits the debugger.
+type quitting struct{}
+
+func debugFile(path string) {
+	expression := parseSource(readSource(path))
+
+	debugger := newDebugger(path, os.Stdin, os.Stdout)
+	fmt.Printf("debugging %s, type \"help\" for a list of commands\n", path)
+
+	value, err, quit := debugger.debug(expression)
+	if quit {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(70)
+	}
+	fmt.Println(lox.Stringify(value))
+}
+
+// newDebugger returns a debugger reading commands from input and writing
+// to output, which stops at the first expression evaluated.
+func newDebugger(path string, input io.Reader, output io.Writer) *debugger {
+	return &debugger{
+		path:        path,
+		input:       bufio.NewScanner(input),
+		output:      output,
+		breakpoints: map[int]bool{},
+		mode:        modeStep,
+	}
+}
+
+// debug evaluates expression under the debugger. quit reports whether the
+// user quit, or the commands ran out, before evaluation finished.
+func (debugger *debugger) debug(expression lox.Expr) (value any, err error, quit bool) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if _, ok := recovered.(quitting); !ok {
+				panic(recovered)
+			}
+			quit = true
+		}
+	}()
+
+	value, err = lox.InterpretWithHook(expression, debugger)
+	return value, err, false
+}
+
+func (debugger *debugger) Enter(expr lox.Expr, depth int) {
+	debugger.stack = append(debugger.stack[:depth], expr)
+
+	if line := expr.Line(); line != 0 {
+		debugger.line = line
+	}
+
+	stop := false
+	switch debugger.mode {
+	case modeStep:
+		stop = true
+	case modeNext:
+		stop = depth <= debugger.modeDepth
+	}
+
+	if debugger.breakpoints[debugger.line] && debugger.line != debugger.stoppedLine {
+		fmt.Fprintf(debugger.output, "Breakpoint at %s:%d\n", debugger.path, debugger.line)
+		stop = true
+	}
+
+	if stop {
+		debugger.stoppedLine = debugger.line
+		debugger.printFrame(depth)
+		debugger.prompt(depth)
+	}
+}
+
+func (debugger *debugger) Exit(expr lox.Expr, value lox.Value, err error, depth int) {
+	debugger.stack = debugger.stack[:depth]
+
+	if debugger.mode != modeFinish || depth > debugger.modeDepth {
+		return
+	}
+
+	fmt.Fprintf(debugger.output, "Run till exit from %s\n", expr.Print())
+	if err != nil {
+		fmt.Fprintf(debugger.output, "Error: %v\n", err)
+	} else {
+		fmt.Fprintf(debugger.output, "Value returned is %s\n", value)
+	}
+	if depth > 0 {
+		debugger.printFrame(depth - 1)
+	}
+	debugger.prompt(depth - 1)
+}
+
+func (debugger *debugger) printFrame(depth int) {
+	fmt.Fprintf(debugger.output, "%s:%d: %s\n", debugger.path, debugger.line, debugger.stack[depth].Print())
+}
+
+// prompt reads commands until one of them resumes execution.
+func (debugger *debugger) prompt(depth int) {
+	for {
+		fmt.Fprint(debugger.output, "(glox) ")
+		if !debugger.input.Scan() {
+			panic(quitting{})
+		}
+
+		command, argument, _ := strings.Cut(strings.TrimSpace(debugger.input.Text()), " ")
+		argument = strings.TrimSpace(argument)
+
+		switch command {
+		case "":
+			continue
+		case "s", "step":
+			debugger.mode = modeStep
+			return
+		case "n", "next":
+			debugger.mode = modeNext
+			debugger.modeDepth = depth
+			return
+		case "finish":
+			if depth < 0 {
+				fmt.Fprintln(debugger.output, "\"finish\" not meaningful in the outermost frame.")
+				continue
+			}
+			debugger.mode = modeFinish
+			debugger.modeDepth = depth
+			return
+		case "c", "continue":
+			debugger.mode = modeContinue
+			return
+		case "b", "break":
+			debugger.setBreakpoint(argument)
+		case "p", "print":
+			debugger.print(argument)
+		case "bt", "backtrace":
+			for i := depth; i >= 0; i-- {
+				fmt.Fprintf(debugger.output, "#%d  %s\n", depth-i, debugger.stack[i].Print())
+			}
+		case "watch":
+			fmt.Fprintln(debugger.output, "watch: this version of lox has no variables to watch")
+		case "q", "quit":
+			panic(quitting{})
+		case "h", "help":
+			fmt.Fprintln(debugger.output, "break [file:]line  stop when evaluation reaches line")
+			fmt.Fprintln(debugger.output, "step               stop at the next expression")
+			fmt.Fprintln(debugger.output, "next               stop at the next expression outside the current one")
+			fmt.Fprintln(debugger.output, "finish             run until the current expression returns")
+			fmt.Fprintln(debugger.output, "continue           run until a breakpoint or the end of the program")
+			fmt.Fprintln(debugger.output, "print <expr>       evaluate expr and print its value")
+			fmt.Fprintln(debugger.output, "bt                 print the expressions being evaluated")
+			fmt.Fprintln(debugger.output, "watch <var>        stop when var changes")
+			fmt.Fprintln(debugger.output, "quit               exit the debugger")
+		default:
+			fmt.Fprintf(debugger.output, "Undefined command: %q. Try \"help\".\n", command)
+		}
+	}
+}
+
+func (debugger *debugger) setBreakpoint(location string) {
+	file, lineText, found := strings.Cut(location, ":")
+	if !found {
+		file, lineText = debugger.path, location
+	}
+
+	line, err := strconv.Atoi(lineText)
+	if err != nil || line < 1 {
+		fmt.Fprintf(debugger.output, "invalid line number %q\n", lineText)
+		return
+	}
+	if file != debugger.path {
+		fmt.Fprintf(debugger.output, "No source file named %s.\n", file)
+		return
+	}
+
+	debugger.breakpoints[line] = true
+	fmt.Fprintf(debugger.output, "Breakpoint at %s:%d\n", debugger.path, line)
+}
+
+func (debugger *debugger) print(source string) {
+	tokens, err := lox.Scan(source)
+	if err != nil {
+		fmt.Fprintln(debugger.output, err)
+		return
+	}
+
+	expression, err := lox.Parse(tokens)
+	if err != nil {
+		return
+	}
+
+	value, err := lox.Interpret(expression)
+	if err != nil {
+		fmt.Fprintln(debugger.output, err)
+		return
+	}
+	fmt.Fprintln(debugger.output, lox.Stringify(value))
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"glox/lox"
+	"strings"
+	"testing"
+)
+
+// debugSource evaluates to -1, with one nesting level per line.
+const debugSource = "1 +\n2 *\n(3 - 4)"
+
+func TestDebugger(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands string
+		expected string
+		value    any
+		quit     bool
+	}{
+		{
+			name:     "step",
+			commands: "step\nstep\nstep\n",
+			expected: `prog.lox:1: (+ 1 (* 2 (group (- 3 4))))
+(glox) prog.lox:1: 1
+(glox) prog.lox:2: (* 2 (group (- 3 4)))
+(glox) prog.lox:2: 2
+(glox) `,
+			quit: true,
+		},
+		{
+			name:     "next stays at the same depth",
+			commands: "next\n",
+			expected: "prog.lox:1: (+ 1 (* 2 (group (- 3 4))))\n(glox) ",
+			value:    -1.0,
+		},
+		{
+			name:     "finish and backtrace",
+			commands: "step\nfinish\nbt\nfinish\nfinish\ncontinue\n",
+			expected: `prog.lox:1: (+ 1 (* 2 (group (- 3 4))))
+(glox) prog.lox:1: 1
+(glox) Run till exit from 1
+Value returned is 1
+prog.lox:1: (+ 1 (* 2 (group (- 3 4))))
+(glox) #0  (+ 1 (* 2 (group (- 3 4))))
+(glox) Run till exit from (+ 1 (* 2 (group (- 3 4))))
+Value returned is -1
+(glox) "finish" not meaningful in the outermost frame.
+(glox) `,
+			value: -1.0,
+		},
+		{
+			name:     "breakpoint stops once per line",
+			commands: "break 3\ncontinue\nbt\ncontinue\n",
+			expected: `prog.lox:1: (+ 1 (* 2 (group (- 3 4))))
+(glox) Breakpoint at prog.lox:3
+(glox) Breakpoint at prog.lox:3
+prog.lox:3: (group (- 3 4))
+(glox) #0  (group (- 3 4))
+#1  (* 2 (group (- 3 4)))
+#2  (+ 1 (* 2 (group (- 3 4))))
+(glox) `,
+			value: -1.0,
+		},
+		{
+			name:     "breakpoint locations",
+			commands: "break prog.lox:2\nbreak other.lox:1\nbreak x\nbreak 0\ncontinue\ncontinue\n",
+			expected: `prog.lox:1: (+ 1 (* 2 (group (- 3 4))))
+(glox) Breakpoint at prog.lox:2
+(glox) No source file named other.lox.
+(glox) invalid line number "x"
+(glox) invalid line number "0"
+(glox) Breakpoint at prog.lox:2
+prog.lox:2: (* 2 (group (- 3 4)))
+(glox) `,
+			value: -1.0,
+		},
+		{
+			name:     "other commands",
+			commands: "print 1 + 2\nprint -\"a\"\nwatch x\nfrob\n\nquit\n",
+			expected: `prog.lox:1: (+ 1 (* 2 (group (- 3 4))))
+(glox) 3
+(glox) not a number
+(glox) watch: this version of lox has no variables to watch
+(glox) Undefined command: "frob". Try "help".
+(glox) (glox) `,
+			quit: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokens, err := lox.Scan(debugSource)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expression, err := lox.Parse(tokens)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var output strings.Builder
+			debugger := newDebugger("prog.lox", strings.NewReader(test.commands), &output)
+			value, err, quit := debugger.debug(expression)
+
+			if output.String() != test.expected {
+				t.Errorf("Incorrect session.\nresult  :\n%s\nexpected:\n%s\n", output.String(), test.expected)
+			}
+			if value != test.value || err != nil || quit != test.quit {
+				t.Errorf("Incorrect result.\nresult  :%v %v %v\nexpected:%v <nil> %v\n", value, err, quit, test.value, test.quit)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package lox
+
+//go:generate go run ../tools/generateast -grammar ast.grammar
+
+import (
+    "fmt"
+    "strings"
+)
+
+// printer renders expressions as parenthesized prefix notation.
+type printer struct{}
+
+var _ ExprVisitor[string] = printer{}
+
+func printExpr(expr Expr) string {
+    result, _ := VisitExpr[string](expr, printer{})
+    return result
+}
+
+func (printer printer) VisitBinary(expr Binary) (string, error) {
+    return printer.parenthesize(expr.operator.lexeme, expr.left, expr.right), nil
+}
+
+func (printer printer) VisitGrouping(expr Grouping) (string, error) {
+    return printer.parenthesize("group", expr.expression), nil
+}
+
+func (printer printer) VisitLiteral(expr Literal) (string, error) {
+    if expr.value == StringVal("") {
+        return "nil", nil
+    }
+    return fmt.Sprint(expr.value.Any()), nil
+}
+
+func (printer printer) VisitUnary(expr Unary) (string, error) {
+    return printer.parenthesize(expr.operator.lexeme, expr.right), nil
+}
+
+func (printer printer) parenthesize(name string, exprs ...Expr) string {
+    var builder strings.Builder
+
+    builder.WriteString("(")
+    builder.WriteString(name)
+    for _, expr := range exprs {
+        builder.WriteString(" ")
+        builder.WriteString(printExpr(expr))
+    }
+    builder.WriteString(")")
+
+    return builder.String()
+}
+
+func (l Literal) String() string {
+    return l.Print()
+}
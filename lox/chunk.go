@@ -0,0 +1,73 @@
+package lox
+
+type OpCode byte
+
+const (
+    OP_CONSTANT OpCode = iota
+    OP_CONSTANT_LONG
+    OP_NIL
+    OP_TRUE
+    OP_FALSE
+    OP_EQUAL
+    OP_NOT_EQUAL
+    OP_GREATER
+    OP_GREATER_EQUAL
+    OP_LESS
+    OP_LESS_EQUAL
+    OP_ADD
+    OP_SUBTRACT
+    OP_MULTIPLY
+    OP_DIVIDE
+    OP_NOT
+    OP_NEGATE
+    OP_RETURN
+)
+
+var opCodeToString = map[OpCode]string {
+    OP_CONSTANT: "OP_CONSTANT",
+    OP_CONSTANT_LONG: "OP_CONSTANT_LONG",
+    OP_NIL: "OP_NIL",
+    OP_TRUE: "OP_TRUE",
+    OP_FALSE: "OP_FALSE",
+    OP_EQUAL: "OP_EQUAL",
+    OP_NOT_EQUAL: "OP_NOT_EQUAL",
+    OP_GREATER: "OP_GREATER",
+    OP_GREATER_EQUAL: "OP_GREATER_EQUAL",
+    OP_LESS: "OP_LESS",
+    OP_LESS_EQUAL: "OP_LESS_EQUAL",
+    OP_ADD: "OP_ADD",
+    OP_SUBTRACT: "OP_SUBTRACT",
+    OP_MULTIPLY: "OP_MULTIPLY",
+    OP_DIVIDE: "OP_DIVIDE",
+    OP_NOT: "OP_NOT",
+    OP_NEGATE: "OP_NEGATE",
+    OP_RETURN: "OP_RETURN",
+}
+
+func (opCode OpCode) String() string {
+    return opCodeToString[opCode]
+}
+
+// Chunk is a compiled sequence of instructions together with the constants
+// they refer to and the source line of every byte of code.
+type Chunk struct {
+    code []byte
+    lines []int
+    constants []Value
+
+    // errorMessages maps the offset of an instruction to the message its
+    // runtime error is reported with. The tree-walker replaces the errors of
+    // subexpressions with a message naming the enclosing operand, and the VM
+    // reproduces that from this table.
+    errorMessages map[int]string
+}
+
+func (chunk *Chunk) write(b byte, line int) {
+    chunk.code = append(chunk.code, b)
+    chunk.lines = append(chunk.lines, line)
+}
+
+func (chunk *Chunk) addConstant(value Value) int {
+    chunk.constants = append(chunk.constants, value)
+    return len(chunk.constants) - 1
+}
@@ -0,0 +1,234 @@
+package lox
+
+import "errors"
+
+// evalFunc evaluates one compiled expression.
+type evalFunc func() (Value, error)
+
+type closureCompiler struct{}
+
+var _ ExprVisitor[evalFunc] = closureCompiler{}
+
+// CompileClosures turns an expression into a tree of Go closures, so that
+// evaluating it repeatedly does no type switching on nodes or operators.
+// The result gives the same values and runtime errors as Interpret.
+func CompileClosures(expr Expr) func() (any, error) {
+    eval := compileClosure(expr)
+
+    return func() (any, error) {
+        value, err := eval()
+        if err != nil {
+            return nil, err
+        }
+        return value.Any(), nil
+    }
+}
+
+func compileClosure(expr Expr) evalFunc {
+    eval, err := VisitExpr[evalFunc](expr, closureCompiler{})
+    if err != nil {
+        return func() (Value, error) {
+            return Value{}, err
+        }
+    }
+    return eval
+}
+
+func (compiler closureCompiler) VisitLiteral(literal Literal) (evalFunc, error) {
+    value := literal.value
+    return func() (Value, error) {
+        return value, nil
+    }, nil
+}
+
+func (compiler closureCompiler) VisitGrouping(grouping Grouping) (evalFunc, error) {
+    return compileClosure(grouping.expression), nil
+}
+
+func (compiler closureCompiler) VisitUnary(unary Unary) (evalFunc, error) {
+    right := compileClosure(unary.right)
+
+    switch unary.operator.tokenType {
+    case MINUS:
+        return func() (Value, error) {
+            value, err := right()
+            if err != nil {
+                return Value{}, errors.New(unaryErrorMessage)
+            }
+            if !value.IsNumber() {
+                return Value{}, errors.New(notANumberMessage)
+            }
+            return NumberVal(-value.AsNumber()), nil
+        }, nil
+    case BANG:
+        return func() (Value, error) {
+            value, err := right()
+            if err != nil {
+                return Value{}, errors.New(unaryErrorMessage)
+            }
+            return BoolVal(!value.isTruthy()), nil
+        }, nil
+    }
+
+    return func() (Value, error) {
+        right()
+        return Value{}, errors.New(unaryErrorMessage)
+    }, nil
+}
+
+// VisitBinary compiles one closure per operator, each evaluating its
+// operands and checking their types itself: going through shared helpers
+// costs an extra call per node.
+func (compiler closureCompiler) VisitBinary(binary Binary) (evalFunc, error) {
+    left := compileClosure(binary.left)
+    right := compileClosure(binary.right)
+
+    switch binary.operator.tokenType {
+    case MINUS:
+        return func() (Value, error) {
+            leftValue, err := left()
+            if err != nil {
+                return Value{}, errors.New(lhsErrorMessage)
+            }
+            rightValue, err := right()
+            if err != nil {
+                return Value{}, errors.New(rhsErrorMessage)
+            }
+            if leftValue.kind != NumberValue || rightValue.kind != NumberValue {
+                return Value{}, errors.New(notANumberMessage)
+            }
+            return NumberVal(leftValue.number - rightValue.number), nil
+        }, nil
+    case SLASH:
+        return func() (Value, error) {
+            leftValue, err := left()
+            if err != nil {
+                return Value{}, errors.New(lhsErrorMessage)
+            }
+            rightValue, err := right()
+            if err != nil {
+                return Value{}, errors.New(rhsErrorMessage)
+            }
+            if leftValue.kind != NumberValue || rightValue.kind != NumberValue {
+                return Value{}, errors.New(notANumberMessage)
+            }
+            return NumberVal(leftValue.number / rightValue.number), nil
+        }, nil
+    case STAR:
+        return func() (Value, error) {
+            leftValue, err := left()
+            if err != nil {
+                return Value{}, errors.New(lhsErrorMessage)
+            }
+            rightValue, err := right()
+            if err != nil {
+                return Value{}, errors.New(rhsErrorMessage)
+            }
+            if leftValue.kind != NumberValue || rightValue.kind != NumberValue {
+                return Value{}, errors.New(notANumberMessage)
+            }
+            return NumberVal(leftValue.number * rightValue.number), nil
+        }, nil
+    case GREATER:
+        return func() (Value, error) {
+            leftValue, err := left()
+            if err != nil {
+                return Value{}, errors.New(lhsErrorMessage)
+            }
+            rightValue, err := right()
+            if err != nil {
+                return Value{}, errors.New(rhsErrorMessage)
+            }
+            if leftValue.kind != NumberValue || rightValue.kind != NumberValue {
+                return Value{}, errors.New(notANumberMessage)
+            }
+            return BoolVal(leftValue.number > rightValue.number), nil
+        }, nil
+    case GREATER_EQUAL:
+        return func() (Value, error) {
+            leftValue, err := left()
+            if err != nil {
+                return Value{}, errors.New(lhsErrorMessage)
+            }
+            rightValue, err := right()
+            if err != nil {
+                return Value{}, errors.New(rhsErrorMessage)
+            }
+            if leftValue.kind != NumberValue || rightValue.kind != NumberValue {
+                return Value{}, errors.New(notANumberMessage)
+            }
+            return BoolVal(leftValue.number >= rightValue.number), nil
+        }, nil
+    case LESS:
+        return func() (Value, error) {
+            leftValue, err := left()
+            if err != nil {
+                return Value{}, errors.New(lhsErrorMessage)
+            }
+            rightValue, err := right()
+            if err != nil {
+                return Value{}, errors.New(rhsErrorMessage)
+            }
+            if leftValue.kind != NumberValue || rightValue.kind != NumberValue {
+                return Value{}, errors.New(notANumberMessage)
+            }
+            return BoolVal(leftValue.number < rightValue.number), nil
+        }, nil
+    case LESS_EQUAL:
+        return func() (Value, error) {
+            leftValue, err := left()
+            if err != nil {
+                return Value{}, errors.New(lhsErrorMessage)
+            }
+            rightValue, err := right()
+            if err != nil {
+                return Value{}, errors.New(rhsErrorMessage)
+            }
+            if leftValue.kind != NumberValue || rightValue.kind != NumberValue {
+                return Value{}, errors.New(notANumberMessage)
+            }
+            return BoolVal(leftValue.number <= rightValue.number), nil
+        }, nil
+    case PLUS:
+        return func() (Value, error) {
+            leftValue, err := left()
+            if err != nil {
+                return Value{}, errors.New(lhsErrorMessage)
+            }
+            rightValue, err := right()
+            if err != nil {
+                return Value{}, errors.New(rhsErrorMessage)
+            }
+            if leftValue.kind == NumberValue && rightValue.kind == NumberValue {
+                return NumberVal(leftValue.number + rightValue.number), nil
+            }
+            return StringVal(leftValue.format() + rightValue.format()), nil
+        }, nil
+    case EQUAL_EQUAL, BANG_EQUAL:
+        negate := binary.operator.tokenType == BANG_EQUAL
+        return func() (Value, error) {
+            leftValue, err := left()
+            if err != nil {
+                return Value{}, errors.New(lhsErrorMessage)
+            }
+            rightValue, err := right()
+            if err != nil {
+                return Value{}, errors.New(rhsErrorMessage)
+            }
+            if leftValue.kind == NumberValue && rightValue.kind == NumberValue {
+                return BoolVal((leftValue.number == rightValue.number) != negate), nil
+            }
+            return BoolVal((leftValue.isTruthy() == rightValue.isTruthy()) != negate), nil
+        }, nil
+    }
+
+    return func() (Value, error) {
+        if _, err := left(); err != nil {
+            return Value{}, errors.New(lhsErrorMessage)
+        }
+        if _, err := right(); err != nil {
+            return Value{}, errors.New(rhsErrorMessage)
+        }
+        return Value{}, errors.New(notANumberMessage)
+    }, nil
+}
@@ -0,0 +1,145 @@
+package lox
+
+import "fmt"
+
+const maxConstants = 1 << 24
+
+type CompileError struct {
+    line int
+    message string
+}
+
+func (compileError CompileError) Error() string {
+    return fmt.Sprintf("[line %d] Error: %s", compileError.line, compileError.message)
+}
+
+type compiler struct {
+    chunk *Chunk
+    errorMessage string
+}
+
+var _ ExprVisitor[any] = (*compiler)(nil)
+
+// Compile translates an expression into a chunk of bytecode for the VM.
+func Compile(expr Expr) (*Chunk, error) {
+    compiler := compiler{chunk: &Chunk{errorMessages: map[int]string{}}}
+
+    if err := compiler.compile(expr, ""); err != nil {
+        return nil, err
+    }
+    compiler.chunk.write(byte(OP_RETURN), expr.Line())
+
+    return compiler.chunk, nil
+}
+
+// compile emits code for expr. errorMessage is the message the tree-walker
+// would report for a runtime error inside expr; the outermost one wins.
+func (compiler *compiler) compile(expr Expr, errorMessage string) error {
+    saved := compiler.errorMessage
+    if compiler.errorMessage == "" {
+        compiler.errorMessage = errorMessage
+    }
+
+    _, err := expr.Accept(compiler)
+    compiler.errorMessage = saved
+
+    return err
+}
+
+func (compiler *compiler) emit(opCode OpCode, line int) {
+    compiler.chunk.write(byte(opCode), line)
+}
+
+// emitFallible emits an instruction that can fail at runtime and records
+// the message its error must be reported with.
+func (compiler *compiler) emitFallible(opCode OpCode, line int) {
+    if compiler.errorMessage != "" {
+        compiler.chunk.errorMessages[len(compiler.chunk.code)] = compiler.errorMessage
+    }
+    compiler.emit(opCode, line)
+}
+
+func (compiler *compiler) VisitLiteral(literal Literal) (any, error) {
+    switch literal.value {
+    case NilVal():
+        compiler.emit(OP_NIL, literal.line)
+        return nil, nil
+    case BoolVal(true):
+        compiler.emit(OP_TRUE, literal.line)
+        return nil, nil
+    case BoolVal(false):
+        compiler.emit(OP_FALSE, literal.line)
+        return nil, nil
+    }
+
+    index := compiler.chunk.addConstant(literal.value)
+    if index >= maxConstants {
+        return nil, CompileError{literal.line, "Too many constants in one chunk."}
+    }
+
+    if index <= 0xff {
+        compiler.emit(OP_CONSTANT, literal.line)
+        compiler.chunk.write(byte(index), literal.line)
+    } else {
+        compiler.emit(OP_CONSTANT_LONG, literal.line)
+        compiler.chunk.write(byte(index >> 16), literal.line)
+        compiler.chunk.write(byte(index >> 8), literal.line)
+        compiler.chunk.write(byte(index), literal.line)
+    }
+    return nil, nil
+}
+
+func (compiler *compiler) VisitGrouping(grouping Grouping) (any, error) {
+    return nil, compiler.compile(grouping.expression, "")
+}
+
+func (compiler *compiler) VisitUnary(unary Unary) (any, error) {
+    if err := compiler.compile(unary.right, unaryErrorMessage); err != nil {
+        return nil, err
+    }
+
+    switch unary.operator.tokenType {
+    case MINUS:
+        compiler.emitFallible(OP_NEGATE, unary.operator.line)
+    case BANG:
+        compiler.emit(OP_NOT, unary.operator.line)
+    default:
+        return nil, CompileError{unary.operator.line, fmt.Sprintf("Unknown unary operator '%s'.", unary.operator.lexeme)}
+    }
+    return nil, nil
+}
+
+var binaryOpCodes = map[TokenType]OpCode {
+    EQUAL_EQUAL: OP_EQUAL,
+    BANG_EQUAL: OP_NOT_EQUAL,
+    GREATER: OP_GREATER,
+    GREATER_EQUAL: OP_GREATER_EQUAL,
+    LESS: OP_LESS,
+    LESS_EQUAL: OP_LESS_EQUAL,
+    PLUS: OP_ADD,
+    MINUS: OP_SUBTRACT,
+    STAR: OP_MULTIPLY,
+    SLASH: OP_DIVIDE,
+}
+
+func (compiler *compiler) VisitBinary(binary Binary) (any, error) {
+    if err := compiler.compile(binary.left, lhsErrorMessage); err != nil {
+        return nil, err
+    }
+    if err := compiler.compile(binary.right, rhsErrorMessage); err != nil {
+        return nil, err
+    }
+
+    opCode, ok := binaryOpCodes[binary.operator.tokenType]
+    if !ok {
+        return nil, CompileError{binary.operator.line, fmt.Sprintf("Unknown binary operator '%s'.", binary.operator.lexeme)}
+    }
+
+    switch opCode {
+    case OP_EQUAL, OP_NOT_EQUAL, OP_ADD:
+        compiler.emit(opCode, binary.operator.line)
+    default:
+        compiler.emitFallible(opCode, binary.operator.line)
+    }
+    return nil, nil
+}
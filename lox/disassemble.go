@@ -0,0 +1,43 @@
+package lox
+
+import (
+    "fmt"
+    "io"
+)
+
+// Disassemble writes a listing of every instruction in the chunk, in the
+// format used by clox.
+func (chunk *Chunk) Disassemble(w io.Writer, name string) {
+    fmt.Fprintf(w, "== %s ==\n", name)
+
+    for offset := 0; offset < len(chunk.code); {
+        offset = chunk.DisassembleInstruction(w, offset)
+    }
+}
+
+// DisassembleInstruction writes the instruction at offset and returns the
+// offset of the next one.
+func (chunk *Chunk) DisassembleInstruction(w io.Writer, offset int) int {
+    fmt.Fprintf(w, "%04d ", offset)
+    if offset > 0 && chunk.lines[offset] == chunk.lines[offset - 1] {
+        fmt.Fprint(w, "   | ")
+    } else {
+        fmt.Fprintf(w, "%4d ", chunk.lines[offset])
+    }
+
+    opCode := OpCode(chunk.code[offset])
+    switch opCode {
+    case OP_CONSTANT, OP_CONSTANT_LONG:
+        index := constantIndex(chunk.code[offset:])
+        fmt.Fprintf(w, "%-16s %4d '%s'\n", opCode, index, chunk.constants[index])
+        return offset + instructionLength(opCode)
+    }
+
+    if _, ok := stackEffects[opCode]; !ok {
+        fmt.Fprintf(w, "Unknown opcode %d\n", opCode)
+        return offset + 1
+    }
+
+    fmt.Fprintf(w, "%s\n", opCode)
+    return offset + 1
+}
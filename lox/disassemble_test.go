@@ -0,0 +1,55 @@
+package lox
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestDisassemble(t *testing.T) {
+    chunk, err := Compile(parseSource(t, "(1.5 + \"a\") ==\n!-nil"))
+    if err != nil {
+        t.Fatalf("compiling failed: %v\n", err)
+    }
+
+    var builder strings.Builder
+    chunk.Disassemble(&builder, "test")
+
+    expected := `== test ==
+0000    1 OP_CONSTANT         0 '1.5'
+0002    | OP_CONSTANT         1 'a'
+0004    | OP_ADD
+0005    2 OP_NIL
+0006    | OP_NEGATE
+0007    | OP_NOT
+0008    1 OP_EQUAL
+0009    | OP_RETURN
+`
+    if builder.String() != expected {
+        t.Errorf("Incorrect listing.\nresult  :\n%s\nexpected:\n%s\n", builder.String(), expected)
+    }
+}
+
+func TestTraceExecution(t *testing.T) {
+    chunk, err := Compile(parseSource(t, "1 - 2"))
+    if err != nil {
+        t.Fatalf("compiling failed: %v\n", err)
+    }
+
+    var builder strings.Builder
+    vm := NewVM()
+    vm.Trace = &builder
+    vm.Run(chunk)
+
+    expected := `          
+0000    1 OP_CONSTANT         0 '1'
+          [ 1 ]
+0002    | OP_CONSTANT         1 '2'
+          [ 1 ][ 2 ]
+0004    | OP_SUBTRACT
+          [ -1 ]
+0005    | OP_RETURN
+`
+    if builder.String() != expected {
+        t.Errorf("Incorrect trace.\nresult  :\n%s\nexpected:\n%s\n", builder.String(), expected)
+    }
+}
@@ -1,66 +1,154 @@
+// Code generated by generateast from ast.grammar; DO NOT EDIT.
+
 package lox
 
-import (
-    "strings"
-    "fmt"
-)
+import "fmt"
 
 type Expr interface {
-    Print() string
+	Print() string
+	Line() int
+	Accept(visitor ExprVisitor[any]) (any, error)
 }
 
-func parenthesize(name string, exprs ...Expr) string {
-    var builder strings.Builder
-
-    builder.WriteString("(")
-    builder.WriteString(name)
-    for _, expr := range exprs {
-        builder.WriteString(" ")
-        builder.WriteString(expr.Print())
-    }
-    builder.WriteString(")")
+// ExprVisitor is implemented by passes over Expr trees, with one method
+// per node type. Asserting that a pass implements it catches node types
+// the pass forgets to handle at compile time.
+type ExprVisitor[R any] interface {
+	VisitBinary(expr Binary) (R, error)
+	VisitGrouping(expr Grouping) (R, error)
+	VisitLiteral(expr Literal) (R, error)
+	VisitUnary(expr Unary) (R, error)
+}
 
-    return builder.String()
+// VisitExpr dispatches expr to the matching method of visitor. Go methods
+// cannot take type parameters, so passes returning something other than any
+// go through VisitExpr instead of Accept.
+func VisitExpr[R any](expr Expr, visitor ExprVisitor[R]) (R, error) {
+	switch expr := expr.(type) {
+	case Binary:
+		return visitor.VisitBinary(expr)
+	case Grouping:
+		return visitor.VisitGrouping(expr)
+	case Literal:
+		return visitor.VisitLiteral(expr)
+	case Unary:
+		return visitor.VisitUnary(expr)
+	}
+	var zero R
+	return zero, fmt.Errorf("unknown expr %T", expr)
 }
 
 type Binary struct {
-	left Expr
+	left     Expr
 	operator Token
-	right Expr
+	right    Expr
+	line     int
+}
+
+func NewBinary(left Expr, operator Token, right Expr, line int) Binary {
+	return Binary{left, operator, right, line}
+}
+
+func (expr Binary) Left() Expr {
+	return expr.left
+}
+
+func (expr Binary) Operator() Token {
+	return expr.operator
+}
+
+func (expr Binary) Right() Expr {
+	return expr.right
+}
+
+func (expr Binary) Print() string {
+	return printExpr(expr)
+}
+
+func (expr Binary) Line() int {
+	return expr.line
 }
 
-func (b Binary) Print() string {
-    return parenthesize(b.operator.lexeme, b.left, b.right)
+func (expr Binary) Accept(visitor ExprVisitor[any]) (any, error) {
+	return visitor.VisitBinary(expr)
 }
 
-type Grouping struct{
+type Grouping struct {
 	expression Expr
+	line       int
 }
 
-func (g Grouping) Print() string {
-    return parenthesize("group", g.expression)
+func NewGrouping(expression Expr, line int) Grouping {
+	return Grouping{expression, line}
+}
+
+func (expr Grouping) Expression() Expr {
+	return expr.expression
+}
+
+func (expr Grouping) Print() string {
+	return printExpr(expr)
+}
+
+func (expr Grouping) Line() int {
+	return expr.line
+}
+
+func (expr Grouping) Accept(visitor ExprVisitor[any]) (any, error) {
+	return visitor.VisitGrouping(expr)
 }
 
 type Literal struct {
-	value any
+	value Value
+	line  int
+}
+
+func NewLiteral(value Value, line int) Literal {
+	return Literal{value, line}
 }
 
-func (l Literal) Print() string {
-    if l.value == "" {
-        return "nil"
-    }
-    return fmt.Sprint(l.value)
+func (expr Literal) Value() Value {
+	return expr.value
 }
 
-func (l Literal) String() string {
-    return l.Print()
+func (expr Literal) Print() string {
+	return printExpr(expr)
+}
+
+func (expr Literal) Line() int {
+	return expr.line
+}
+
+func (expr Literal) Accept(visitor ExprVisitor[any]) (any, error) {
+	return visitor.VisitLiteral(expr)
 }
 
 type Unary struct {
 	operator Token
-	right Expr
+	right    Expr
+	line     int
+}
+
+func NewUnary(operator Token, right Expr, line int) Unary {
+	return Unary{operator, right, line}
+}
+
+func (expr Unary) Operator() Token {
+	return expr.operator
+}
+
+func (expr Unary) Right() Expr {
+	return expr.right
+}
+
+func (expr Unary) Print() string {
+	return printExpr(expr)
+}
+
+func (expr Unary) Line() int {
+	return expr.line
 }
 
-func (u Unary) Print() string {
-    return parenthesize(u.operator.lexeme, u.right)
+func (expr Unary) Accept(visitor ExprVisitor[any]) (any, error) {
+	return visitor.VisitUnary(expr)
 }
@@ -5,12 +5,12 @@ import "testing"
 func TestPrint(t *testing.T) {
     expr := Binary{
         left: Unary{
-            operator: Token{MINUS, "-", "", 1},
-            right: Literal{123},
+            operator: Token{tokenType: MINUS, lexeme: "-", literal: StringVal(""), line: 1},
+            right: Literal{value: NumberVal(123)},
         },
-        operator: Token{STAR, "*", "", 1},
+        operator: Token{tokenType: STAR, lexeme: "*", literal: StringVal(""), line: 1},
         right: Grouping{
-            expression: Literal{45.67},
+            expression: Literal{value: NumberVal(45.67)},
         },
     }
 
@@ -21,3 +21,50 @@ func TestPrint(t *testing.T) {
         t.Errorf("incorrect result.\nresult: %v\nexpected: %v\n", result, expected)
     }
  }
+
+type depthVisitor struct{}
+
+func (visitor depthVisitor) VisitBinary(expr Binary) (int, error) {
+    left, _ := VisitExpr[int](expr.left, visitor)
+    right, _ := VisitExpr[int](expr.right, visitor)
+    return max(left, right) + 1, nil
+}
+
+func (visitor depthVisitor) VisitGrouping(expr Grouping) (int, error) {
+    depth, _ := VisitExpr[int](expr.expression, visitor)
+    return depth + 1, nil
+}
+
+func (visitor depthVisitor) VisitLiteral(expr Literal) (int, error) {
+    return 1, nil
+}
+
+func (visitor depthVisitor) VisitUnary(expr Unary) (int, error) {
+    depth, _ := VisitExpr[int](expr.right, visitor)
+    return depth + 1, nil
+}
+
+func TestVisitExpr(t *testing.T) {
+    expr := Binary{
+        left: Literal{value: NumberVal(1.0)},
+        operator: Token{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+        right: Grouping{
+            expression: Unary{
+                operator: Token{tokenType: MINUS, lexeme: "-", literal: StringVal(""), line: 1},
+                right: Literal{value: NumberVal(2.0)},
+            },
+        },
+    }
+
+    result, err := VisitExpr[int](expr, depthVisitor{})
+    if err != nil {
+        t.Errorf("no error expected\n")
+    }
+    if result != 4 {
+        t.Errorf("incorrect result.\nresult: %v\nexpected: %v\n", result, 4)
+    }
+
+    if _, err := VisitExpr[int](nil, depthVisitor{}); err == nil {
+        t.Errorf("expected an error for a nil expression\n")
+    }
+}
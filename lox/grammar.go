@@ -0,0 +1,198 @@
+package lox
+
+import "slices"
+
+// Precedence is the binding power of an infix operator: operators with a
+// higher precedence bind more tightly. The levels of Lox are spaced apart so
+// that operators can be added between them.
+type Precedence int
+
+const (
+	PrecedenceNone       Precedence = 0
+	PrecedenceAssignment Precedence = 10
+	PrecedenceOr         Precedence = 20
+	PrecedenceAnd        Precedence = 30
+	PrecedenceEquality   Precedence = 40
+	PrecedenceComparison Precedence = 50
+	PrecedenceTerm       Precedence = 60
+	PrecedenceFactor     Precedence = 70
+	PrecedenceUnary      Precedence = 80
+	PrecedenceCall       Precedence = 90
+	PrecedencePrimary    Precedence = 100
+)
+
+type Associativity int
+
+const (
+	LeftAssociative Associativity = iota
+	RightAssociative
+)
+
+// A PrefixParselet parses an expression that starts with token, which has
+// been consumed.
+type PrefixParselet func(parser *Parser, token Token) (Expr, error)
+
+// An InfixParselet parses the rest of an expression whose first operand,
+// left, has been parsed and whose operator, token, has been consumed.
+type InfixParselet func(parser *Parser, left Expr, token Token) (Expr, error)
+
+// ParseRule says how a token type parses at the start of an expression and
+// after an operand. Precedence is that of the infix operator.
+type ParseRule struct {
+	Prefix     PrefixParselet
+	Infix      InfixParselet
+	Precedence Precedence
+}
+
+// Grammar is the table of parse rules driving a Parser. Parselets must only
+// depend on the tokens they read, so that a Document can reuse their
+// results.
+type Grammar struct {
+	rules [EOF + 1]ParseRule
+
+	// precedences are the distinct infix precedences, in increasing order.
+	precedences []Precedence
+}
+
+// loxGrammar is used by parsers without a grammar of their own. It is set
+// up in init, as its parselets refer back to it through the parser.
+var loxGrammar *Grammar
+
+func init() {
+	loxGrammar = NewGrammar()
+}
+
+// NewGrammar returns the grammar of Lox expressions, which can then be
+// extended or changed.
+func NewGrammar() *Grammar {
+	grammar := &Grammar{}
+
+	grammar.Prefix(FALSE, literalParselet(BoolVal(false)))
+	grammar.Prefix(TRUE, literalParselet(BoolVal(true)))
+	grammar.Prefix(NIL, literalParselet(NilVal()))
+	grammar.Prefix(NUMBER, tokenLiteral)
+	grammar.Prefix(STRING, tokenLiteral)
+	grammar.Prefix(LEFT_PAREN, grouping)
+
+	grammar.UnaryOperator(BANG)
+	grammar.UnaryOperator(MINUS)
+
+	grammar.BinaryOperator(BANG_EQUAL, PrecedenceEquality, LeftAssociative)
+	grammar.BinaryOperator(EQUAL_EQUAL, PrecedenceEquality, LeftAssociative)
+	grammar.BinaryOperator(GREATER, PrecedenceComparison, LeftAssociative)
+	grammar.BinaryOperator(GREATER_EQUAL, PrecedenceComparison, LeftAssociative)
+	grammar.BinaryOperator(LESS, PrecedenceComparison, LeftAssociative)
+	grammar.BinaryOperator(LESS_EQUAL, PrecedenceComparison, LeftAssociative)
+	grammar.BinaryOperator(MINUS, PrecedenceTerm, LeftAssociative)
+	grammar.BinaryOperator(PLUS, PrecedenceTerm, LeftAssociative)
+	grammar.BinaryOperator(SLASH, PrecedenceFactor, LeftAssociative)
+	grammar.BinaryOperator(STAR, PrecedenceFactor, LeftAssociative)
+
+	return grammar
+}
+
+// Rule returns the parse rule of tokenType.
+func (grammar *Grammar) Rule(tokenType TokenType) ParseRule {
+	return grammar.rules[tokenType]
+}
+
+// SetRule replaces the parse rule of tokenType. Unlike Infix, it does not
+// check that a rule with an Infix parselet has a Precedence above
+// PrecedenceNone; one that does not is never applied.
+func (grammar *Grammar) SetRule(tokenType TokenType, rule ParseRule) {
+	grammar.rules[tokenType] = rule
+
+	grammar.precedences = grammar.precedences[:0]
+	for _, rule := range grammar.rules {
+		if rule.Infix != nil && !slices.Contains(grammar.precedences, rule.Precedence) {
+			grammar.precedences = append(grammar.precedences, rule.Precedence)
+		}
+	}
+	slices.Sort(grammar.precedences)
+}
+
+// Prefix makes parselet parse expressions starting with tokenType.
+func (grammar *Grammar) Prefix(tokenType TokenType, parselet PrefixParselet) {
+	rule := grammar.rules[tokenType]
+	rule.Prefix = parselet
+	grammar.SetRule(tokenType, rule)
+}
+
+// Infix makes parselet parse expressions continuing with tokenType, an
+// operator with the given precedence, which must be above PrecedenceNone.
+func (grammar *Grammar) Infix(tokenType TokenType, precedence Precedence, parselet InfixParselet) {
+	if precedence <= PrecedenceNone {
+		panic("lox: infix operator without precedence")
+	}
+
+	rule := grammar.rules[tokenType]
+	rule.Infix = parselet
+	rule.Precedence = precedence
+	grammar.SetRule(tokenType, rule)
+}
+
+// UnaryOperator makes tokenType a prefix operator building Unary
+// expressions.
+func (grammar *Grammar) UnaryOperator(tokenType TokenType) {
+	grammar.Prefix(tokenType, unaryParselet)
+}
+
+// BinaryOperator makes tokenType an infix operator building Binary
+// expressions.
+func (grammar *Grammar) BinaryOperator(tokenType TokenType, precedence Precedence, associativity Associativity) {
+	// A left-associative operator's right operand may only contain operators
+	// binding more tightly, while a right-associative one's may contain the
+	// operator itself.
+	operand := precedence
+	if associativity == RightAssociative {
+		operand--
+	}
+
+	grammar.Infix(tokenType, precedence, func(parser *Parser, left Expr, operator Token) (Expr, error) {
+		right, err := parser.ParsePrecedence(operand)
+		if err != nil {
+			return nil, ParserError{}
+		}
+		return Binary{left, operator, right, left.Line()}, nil
+	})
+}
+
+// level numbers the precedences that parse differently: parsing at a
+// precedence only depends on which infix operators bind more tightly.
+func (grammar *Grammar) level(precedence Precedence) int {
+	level, _ := slices.BinarySearch(grammar.precedences, precedence+1)
+	return level
+}
+
+func (grammar *Grammar) levels() int {
+	return len(grammar.precedences) + 1
+}
+
+func literalParselet(value Value) PrefixParselet {
+	return func(parser *Parser, token Token) (Expr, error) {
+		return Literal{value, token.line}, nil
+	}
+}
+
+func tokenLiteral(parser *Parser, token Token) (Expr, error) {
+	return Literal{token.literal, token.line}, nil
+}
+
+func grouping(parser *Parser, paren Token) (Expr, error) {
+	expr, err := parser.ParsePrecedence(PrecedenceNone)
+	if err != nil {
+		return nil, ParserError{}
+	}
+
+	// A missing parenthesis is reported, but the grouping is still used.
+	parser.Expect(RIGHT_PAREN, "Expect ')' after expression")
+	return Grouping{expr, paren.line}, nil
+}
+
+func unaryParselet(parser *Parser, operator Token) (Expr, error) {
+	right, err := parser.ParsePrecedence(PrecedenceUnary)
+	if err != nil {
+		return nil, ParserError{}
+	}
+	return Unary{operator, right, operator.line}, nil
+}
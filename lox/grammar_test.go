@@ -0,0 +1,132 @@
+package lox_test
+
+import (
+	"glox/lox"
+	"io"
+	"log"
+	"testing"
+)
+
+func TestGrammarExtension(t *testing.T) {
+	grammar := lox.NewGrammar()
+	grammar.BinaryOperator(lox.EQUAL, lox.PrecedenceAssignment, lox.RightAssociative)
+	grammar.BinaryOperator(lox.OR, lox.PrecedenceOr, lox.LeftAssociative)
+	grammar.BinaryOperator(lox.AND, lox.PrecedenceAnd, lox.LeftAssociative)
+	grammar.BinaryOperator(lox.DOT, lox.PrecedenceTerm+5, lox.LeftAssociative)
+	grammar.UnaryOperator(lox.PLUS)
+	grammar.Prefix(lox.IDENTIFIER, func(parser *lox.Parser, token lox.Token) (lox.Expr, error) {
+		return lox.NewLiteral(lox.StringVal(token.Lexeme()), token.Line()), nil
+	})
+
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			name:     "right-associative operator",
+			source:   "a = b = 1 + 2",
+			expected: "(= a (= b (+ 1 2)))",
+		},
+		{
+			name:     "operators below equality",
+			source:   "1 == 2 or 3 and 4 or 5",
+			expected: "(or (or (== 1 2) (and 3 4)) 5)",
+		},
+		{
+			name:     "operator between levels",
+			source:   "1 + 2 . 3 . 4 * 5",
+			expected: "(+ 1 (. (. 2 3) (* 4 5)))",
+		},
+		{
+			name:     "prefix operator",
+			source:   "+1 - -+2",
+			expected: "(- (+ 1) (- (+ 2)))",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokens, err := lox.Scan(test.source)
+			if err != nil {
+				t.Fatalf("scanning failed: %v\n", err)
+			}
+
+			parser := lox.Parser{Grammar: grammar}
+			expression, err := parser.Parse(tokens)
+			if err != nil {
+				t.Fatalf("parsing failed: %v\n", err)
+			}
+
+			if expression.Print() != test.expected {
+				t.Errorf("result was incorrect.\nresult  :%s\nexpected:%s\n", expression.Print(), test.expected)
+			}
+		})
+	}
+
+	// Extending a grammar leaves that of Lox alone.
+	tokens, _ := lox.Scan("1 and 2")
+	expression, _ := lox.Parse(tokens)
+	if expression.Print() != "1" {
+		t.Errorf("result was incorrect.\nresult  :%s\nexpected:%s\n", expression.Print(), "1")
+	}
+}
+
+func TestGrammarRecovery(t *testing.T) {
+	output := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(output)
+
+	// Braces group like parentheses, but stand for nil if what they enclose
+	// does not parse.
+	grammar := lox.NewGrammar()
+	grammar.Prefix(lox.LEFT_BRACE, func(parser *lox.Parser, brace lox.Token) (lox.Expr, error) {
+		expr, err := parser.ParsePrecedence(lox.PrecedenceNone)
+		if err != nil {
+			for !parser.Check(lox.RIGHT_BRACE) && !parser.Check(lox.EOF) {
+				parser.Advance()
+			}
+			expr = lox.NewLiteral(lox.NilVal(), brace.Line())
+		}
+		parser.Expect(lox.RIGHT_BRACE, "Expect '}' after expression")
+		return lox.NewGrouping(expr, brace.Line()), nil
+	})
+
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			name:     "missing operand",
+			source:   "{+} + (1)",
+			expected: "(+ (group <nil>) (group 1))",
+		},
+		{
+			name:     "nested too deeply",
+			source:   "{(((1)))} + (1)",
+			expected: "(+ (group <nil>) (group 1))",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokens, err := lox.Scan(test.source)
+			if err != nil {
+				t.Fatalf("scanning failed: %v\n", err)
+			}
+
+			// The failed parse inside the braces must not count toward the
+			// nesting of what follows them.
+			parser := lox.Parser{Grammar: grammar, MaxDepth: 3}
+			expression, err := parser.Parse(tokens)
+			if err != nil {
+				t.Fatalf("parsing failed: %v\n", err)
+			}
+
+			if expression.Print() != test.expected {
+				t.Errorf("result was incorrect.\nresult  :%s\nexpected:%s\n", expression.Print(), test.expected)
+			}
+		})
+	}
+}
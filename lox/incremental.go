@@ -0,0 +1,263 @@
+package lox
+
+import (
+    "fmt"
+    "slices"
+    "sort"
+)
+
+// Edit replaces the bytes [Start, End) of a document's text with Text.
+type Edit struct {
+    Start int
+    End int
+    Text string
+}
+
+// Document is a source text kept scanned and parsed across edits, for
+// editors and other tools that re-parse on every keystroke. An edit re-scans
+// only the tokens around the changed text and re-parses only the rules whose
+// tokens changed, reusing everything else from the previous parse. The
+// resulting tokens and tree are the same as those of scanning and parsing
+// the new text from scratch.
+type Document struct {
+    text string
+    tokens []Token
+    expr Expr
+    err error
+
+    parser Parser
+    memo memoTable
+
+    // relexed and reused count the tokens scanned and the rule results
+    // reused by the last update.
+    relexed int
+    reused int
+}
+
+// NewDocument scans and parses text as Lox. A document is returned even if
+// that fails, so that later edits can fix the text.
+func NewDocument(text string) (*Document, error) {
+    var parser Parser
+    return parser.ParseDocument(text)
+}
+
+// ParseDocument is NewDocument parsing with the parser's Grammar and
+// MaxDepth, which the document keeps using for its edits. The parser itself
+// is left alone and can go on parsing other input.
+func (parser *Parser) ParseDocument(text string) (*Document, error) {
+    document := &Document{
+        text: text,
+        parser: Parser{Grammar: parser.Grammar, MaxDepth: parser.MaxDepth},
+    }
+    document.rescan()
+    return document, document.err
+}
+
+func (document *Document) Text() string {
+    return document.text
+}
+
+// Tokens returns the tokens of the text, or nil if it could not be scanned.
+// The slice is updated in place by the next edit.
+func (document *Document) Tokens() []Token {
+    return document.tokens
+}
+
+// Expr returns the expression tree of the text, or nil if it could not be
+// parsed.
+func (document *Document) Expr() Expr {
+    return document.expr
+}
+
+// Edit applies edit to the text and brings the tokens and tree up to date.
+// It returns the new tree, or the error scanning or parsing the new text.
+func (document *Document) Edit(edit Edit) (Expr, error) {
+    if edit.Start < 0 || edit.Start > edit.End || edit.End > len(document.text) {
+        return nil, fmt.Errorf("edit [%d, %d) out of range [0, %d]", edit.Start, edit.End, len(document.text))
+    }
+
+    old := document.text
+    document.text = old[:edit.Start] + edit.Text + old[edit.End:]
+
+    if document.tokens == nil {
+        document.rescan()
+    } else {
+        document.relex(edit)
+    }
+    return document.expr, document.err
+}
+
+func (document *Document) rescan() {
+    scanner := stringScanner(document.text)
+    document.tokens, document.memo = nil, memoTable{}
+
+    tokens, err := scanFrom(&scanner, nil, func(Token) bool { return false })
+    document.relexed = len(tokens)
+    if err != nil {
+        document.expr, document.err = nil, err
+        return
+    }
+
+    document.tokens = tokens
+    document.memo = newMemoTable(len(tokens), document.parser.grammar().levels())
+    document.parse()
+}
+
+// relex updates the tokens for edit, which has already been applied to the
+// text. Scanning restarts after the last token that cannot have been
+// affected by the edit, and stops as soon as it reaches the start of a
+// token after the edited text: the scanner keeps no state between tokens
+// but the line, so the rest of the tokens are the old ones moved by the
+// size of the edit. The tokens and memo are spliced in place, so an edit
+// only costs time for what it changes and the tokens after it.
+func (document *Document) relex(edit Edit) {
+    delta := len(edit.Text) - (edit.End - edit.Start)
+    tokens := document.tokens
+
+    // Scanning a token may look up to two bytes past its end, when checking
+    // whether a number continues with a fraction.
+    kept := sort.Search(len(tokens), func(i int) bool {
+        return tokens[i].End() + 2 > edit.Start
+    })
+
+    scanner := stringScanner(document.text)
+    if kept > 0 {
+        scanner.current = tokens[kept - 1].End()
+        scanner.line = tokens[kept - 1].line
+    }
+
+    // resume is the first old token that may be picked up again.
+    resume := kept
+    scanned, err := scanFrom(&scanner, nil, func(token Token) bool {
+        if token.offset < edit.Start + len(edit.Text) {
+            return false
+        }
+        for resume < len(tokens) && tokens[resume].offset + delta < token.offset {
+            resume++
+        }
+        return resume < len(tokens) && tokens[resume].offset + delta == token.offset
+    })
+    if err != nil {
+        document.tokens, document.memo = nil, memoTable{}
+        document.expr, document.err = nil, err
+        return
+    }
+
+    // The token the scanning stopped at is the old one at resume, moved.
+    lineDelta := scanned[len(scanned) - 1].line - tokens[resume].line
+    scanned = scanned[:len(scanned) - 1]
+    document.relexed = len(scanned)
+
+    for i := range tokens[resume:] {
+        tokens[resume + i].offset += delta
+        tokens[resume + i].line += lineDelta
+    }
+
+    document.tokens = slices.Replace(tokens, kept, resume, scanned...)
+    document.memo.splice(kept, resume, len(scanned))
+    document.parse()
+}
+
+// reach is how many tokens after its start the entry's result, or failing
+// that its last step, examined.
+func (entry *memoEntry) reach() int {
+    if entry.expr == nil && len(entry.steps) > 0 {
+        return entry.steps[len(entry.steps) - 1].length
+    }
+    return entry.length
+}
+
+// splice replaces the entries of the tokens [kept, resume) with empty ones
+// for count new tokens. Results before kept that examined any of those
+// tokens are stale and dropped too, keeping only the steps to them that did
+// not. All others are still valid, though the ones after resume are
+// relocated when reused.
+func (memo *memoTable) splice(kept int, resume int, count int) {
+    for position, longest := range memo.longest[:kept] {
+        if position + longest < kept {
+            continue
+        }
+
+        memo.longest[position] = 0
+        for level := 0; level < memo.levels; level++ {
+            entry := memo.entry(position, level)
+            if position + entry.reach() >= kept {
+                valid := sort.Search(len(entry.steps), func(i int) bool {
+                    return position + entry.steps[i].length >= kept
+                })
+                *entry = memoEntry{offset: entry.offset, line: entry.line, steps: entry.steps[:valid]}
+            }
+            memo.longest[position] = max(memo.longest[position], entry.reach())
+        }
+    }
+
+    memo.entries = slices.Replace(memo.entries, kept * memo.levels, resume * memo.levels, make([]memoEntry, count * memo.levels)...)
+    memo.longest = slices.Replace(memo.longest, kept, resume, make([]int, count)...)
+}
+
+func (document *Document) parse() {
+    document.expr, document.err = document.parser.parseMemoized(document.tokens, &document.memo)
+    document.reused = document.parser.reused
+}
+
+// scanFrom appends the tokens scanner produces to tokens until stop returns
+// true for one, which is appended too, or up to and including EOF.
+func scanFrom(scanner *Scanner, tokens []Token, stop func(Token) bool) ([]Token, error) {
+    for {
+        token, err := scanner.Next()
+        if err != nil {
+            return nil, err
+        }
+
+        tokens = append(tokens, token)
+        if stop(token) || token.tokenType == EOF {
+            return tokens, nil
+        }
+    }
+}
+
+// relocate returns expr with its tokens moved by offset bytes and line
+// lines. Subtrees without tokens whose line stays the same are shared
+// rather than copied.
+func relocate(expr Expr, offset int, line int) Expr {
+    expr, _ = relocated(expr, offset, line)
+    return expr
+}
+
+// relocated is relocate, also reporting whether anything moved.
+func relocated(expr Expr, offset int, line int) (Expr, bool) {
+    switch node := expr.(type) {
+    case Binary:
+        node.left, _ = relocated(node.left, offset, line)
+        node.operator = moveToken(node.operator, offset, line)
+        node.right, _ = relocated(node.right, offset, line)
+        node.line += line
+        return node, true
+    case Grouping:
+        inner, moved := relocated(node.expression, offset, line)
+        if !moved && line == 0 {
+            return expr, false
+        }
+        node.expression = inner
+        node.line += line
+        return node, true
+    case Literal:
+        if line == 0 {
+            return expr, false
+        }
+        node.line += line
+        return node, true
+    case Unary:
+        node.operator = moveToken(node.operator, offset, line)
+        node.right, _ = relocated(node.right, offset, line)
+        node.line += line
+        return node, true
+    }
+    return expr, false
+}
+
+func moveToken(token Token, offset int, line int) Token {
+    token.offset += offset
+    token.line += line
+    return token
+}
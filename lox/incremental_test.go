@@ -0,0 +1,306 @@
+package lox
+
+import (
+    "io"
+    "log"
+    "math/rand"
+    "strings"
+    "testing"
+)
+
+func TestDocumentEdit(t *testing.T) {
+    tests := []struct {
+        name string
+        text string
+        edit Edit
+        expected string
+        maxRelexed int
+    } {
+        {
+            name: "replace a number",
+            text: "(1 + 2) * (3 + 4)",
+            edit: Edit{Start: 11, End: 12, Text: "30"},
+            expected: "(* (group (+ 1 2)) (group (+ 30 4)))",
+            maxRelexed: 2,
+        },
+        {
+            name: "insert an operator",
+            text: "(1 + 2) * (3 + 4)",
+            edit: Edit{Start: 2, End: 2, Text: " * 5"},
+            expected: "(* (group (+ (* 1 5) 2)) (group (+ 3 4)))",
+            maxRelexed: 4,
+        },
+        {
+            name: "join two tokens",
+            text: "1 = = 2",
+            edit: Edit{Start: 3, End: 4, Text: ""},
+            expected: "(== 1 2)",
+            maxRelexed: 1,
+        },
+        {
+            name: "extend a number with a fraction",
+            text: "12 + 3",
+            edit: Edit{Start: 2, End: 2, Text: ".5"},
+            expected: "(+ 12.5 3)",
+            maxRelexed: 1,
+        },
+        {
+            name: "comment out a line",
+            text: "1 +\n2 +\n3",
+            edit: Edit{Start: 4, End: 4, Text: "// "},
+            expected: "(+ 1 3)",
+            maxRelexed: 1,
+        },
+        {
+            name: "change the operator after a step of a chain",
+            text: "1 + 2   + 3",
+            edit: Edit{Start: 8, End: 9, Text: "*"},
+            expected: "(+ 1 (* 2 3))",
+            maxRelexed: 1,
+        },
+        {
+            name: "extend a chain",
+            text: "1 + 2 + 3 == 4",
+            edit: Edit{Start: 14, End: 14, Text: " + 5"},
+            expected: "(== (+ (+ 1 2) 3) (+ 4 5))",
+            maxRelexed: 3,
+        },
+        {
+            name: "replace a number with a string",
+            text: "\"a\" + 1",
+            edit: Edit{Start: 6, End: 7, Text: "\"b\""},
+            expected: "(+ a b)",
+            maxRelexed: 2,
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            document, err := NewDocument(test.text)
+            if err != nil {
+                t.Fatalf("parsing failed: %v\n", err)
+            }
+
+            expr, err := document.Edit(test.edit)
+            if err != nil {
+                t.Fatalf("parsing the edited text failed: %v\n", err)
+            }
+
+            if expr.Print() != test.expected {
+                t.Errorf("Incorrect result.\nresult  :%v\nexpected:%v\n", expr.Print(), test.expected)
+            }
+            if document.relexed > test.maxRelexed {
+                t.Errorf("Scanned %d tokens, expected at most %d.\n", document.relexed, test.maxRelexed)
+            }
+            assertFullParse(t, Parser{}, document)
+        })
+    }
+}
+
+func TestDocumentReusesSubtrees(t *testing.T) {
+    text := strings.Repeat("(1 + 2 * 3) == ", 50) + "(4 - 5)"
+    document, err := NewDocument(text)
+    if err != nil {
+        t.Fatalf("parsing failed: %v\n", err)
+    }
+
+    // Every grouping but the edited one is reused, the ones after the edit
+    // with their lines and offsets moved.
+    document.Edit(Edit{Start: 1, End: 2, Text: "\n10"})
+    assertFullParse(t, Parser{}, document)
+
+    if document.reused < 49 {
+        t.Errorf("Reused %d results, expected at least 49.\n", document.reused)
+    }
+}
+
+func TestDocumentEditsAfterError(t *testing.T) {
+    output := log.Writer()
+    log.SetOutput(io.Discard)
+    defer log.SetOutput(output)
+
+    // Removing the last operand leaves the partial results of the chain
+    // unused, and the next edit moves them before they are picked up again.
+    document, _ := NewDocument("1 + 2 + 3 + 4")
+    for _, edit := range []Edit{
+        {Start: 12, End: 13, Text: ""},
+        {Start: 0, End: 0, Text: "\n"},
+        {Start: 13, End: 13, Text: "4"},
+    } {
+        document.Edit(edit)
+        assertFullParse(t, Parser{}, document)
+        if t.Failed() {
+            t.Fatalf("Edit %+v left %q out of date.\n", edit, document.Text())
+        }
+    }
+}
+
+func TestDocumentMaxDepth(t *testing.T) {
+    output := log.Writer()
+    log.SetOutput(io.Discard)
+    defer log.SetOutput(output)
+
+    parser := Parser{MaxDepth: 4}
+    document, _ := parser.ParseDocument("")
+    if _, err := document.Edit(Edit{Start: 0, End: 0, Text: "(1) + ((2))"}); err != nil {
+        t.Fatalf("parsing failed: %v\n", err)
+    }
+
+    // Moving ((2)) into the first grouping nests it too deeply, even though
+    // its tokens are unchanged.
+    if _, err := document.Edit(Edit{Start: 2, End: 6, Text: " + "}); err == nil {
+        t.Errorf("Expected %q to nest too deeply.\n", document.Text())
+    }
+    assertFullParse(t, parser, document)
+}
+
+func TestDocumentGrammar(t *testing.T) {
+    grammar := NewGrammar()
+    grammar.BinaryOperator(AND, PrecedenceAnd, LeftAssociative)
+    parser := Parser{Grammar: grammar}
+
+    document, err := parser.ParseDocument("1 and 2")
+    if err != nil {
+        t.Fatalf("parsing failed: %v\n", err)
+    }
+
+    // Edits keep parsing with the grammar of the document.
+    expr, err := document.Edit(Edit{Start: 7, End: 7, Text: " and 3"})
+    if err != nil {
+        t.Fatalf("parsing failed: %v\n", err)
+    }
+    if expr.Print() != "(and (and 1 2) 3)" {
+        t.Errorf("Incorrect result.\nresult  :%v\nexpected:%v\n", expr.Print(), "(and (and 1 2) 3)")
+    }
+    assertFullParse(t, parser, document)
+}
+
+func TestDocumentRandomEdits(t *testing.T) {
+    output := log.Writer()
+    log.SetOutput(io.Discard)
+    defer log.SetOutput(output)
+
+    fragments := []string{
+        "1", "23", ".5", "4.25", "\"s\"", "\"", "true", "false", "nil", "x",
+        "+", "-", "*", "/", "!", "!=", "=", "==", "<", "<=", ">", ">=",
+        "(", ")", " ", "\n", "// note\n", "/", "@",
+    }
+    random := rand.New(rand.NewSource(1))
+
+    for run := 0; run < 200; run++ {
+        text := randomExpression(random, 4)
+        // A low nesting limit checks that results are only reused where
+        // they do not nest too deeply.
+        parser := Parser{MaxDepth: 1 + run % 4}
+        document, _ := parser.ParseDocument(text)
+
+        for step := 0; step < 30; step++ {
+            length := len(document.Text())
+            start := random.Intn(length + 1)
+            end := start + random.Intn(min(length - start, 4) + 1)
+
+            var inserted strings.Builder
+            for i := random.Intn(3); i > 0; i-- {
+                inserted.WriteString(fragments[random.Intn(len(fragments))])
+            }
+
+            edit := Edit{Start: start, End: end, Text: inserted.String()}
+            document.Edit(edit)
+            assertFullParse(t, parser, document)
+            if t.Failed() {
+                t.Fatalf("Edit %+v left %q out of date.\n", edit, document.Text())
+            }
+        }
+    }
+}
+
+func randomExpression(random *rand.Rand, depth int) string {
+    if depth == 0 {
+        return []string{"1", "2.5", "\"a\"", "true", "nil"}[random.Intn(5)]
+    }
+
+    switch random.Intn(4) {
+    case 0:
+        return "(" + randomExpression(random, depth - 1) + ")"
+    case 1:
+        return []string{"-", "!"}[random.Intn(2)] + randomExpression(random, depth - 1)
+    default:
+        operators := []string{" + ", " - ", " * ", " / ", " == ", " != ", " < ", " >= ", "\n+ "}
+        return randomExpression(random, depth - 1) + operators[random.Intn(len(operators))] + randomExpression(random, depth - 1)
+    }
+}
+
+// assertFullParse checks that the document's tokens and tree are the same
+// as those of scanning its text from scratch and parsing it with parser.
+func assertFullParse(t *testing.T, parser Parser, document *Document) {
+    t.Helper()
+
+    tokens, err := Scan(document.Text())
+    if err != nil {
+        if document.Tokens() != nil {
+            t.Errorf("Expected scan error %v.\n", err)
+        }
+        return
+    }
+
+    if len(document.Tokens()) != len(tokens) {
+        t.Errorf("Incorrect tokens.\nresult  :%v\nexpected:%v\n", document.Tokens(), tokens)
+        return
+    }
+    for i, token := range tokens {
+        if document.Tokens()[i] != token {
+            t.Errorf("Incorrect token %d.\nresult  :%v\nexpected:%v\n", i, document.Tokens()[i], token)
+            return
+        }
+    }
+
+    expr, err := parser.Parse(tokens)
+    if expr != document.Expr() || (err == nil) != (document.err == nil) {
+        t.Errorf("Incorrect tree.\nresult  :%+v\nexpected:%+v\n", document.Expr(), expr)
+    }
+}
+
+func BenchmarkDocumentEdit(b *testing.B) {
+    text := strings.Repeat("(1 + 2 * 3) ==\n", 3000) + "(4 - 5)"
+    middle := len(text) / 2 + strings.Index(text[len(text) / 2:], "1") + 1
+
+    b.Run("full parse", func(b *testing.B) {
+        b.ReportAllocs()
+        for i := 0; i < b.N; i++ {
+            tokens, _ := Scan(text)
+            Parse(tokens)
+        }
+    })
+
+    // Each iteration inserts a digit after the given one, or removes it
+    // again, so the text alternates between two versions.
+    for _, at := range []struct {
+        name string
+        offset int
+    } {
+        {name: "edit at start", offset: 2},
+        {name: "edit in middle", offset: middle},
+        {name: "edit at end", offset: len(text) - 5},
+    } {
+        b.Run(at.name, func(b *testing.B) {
+            document, err := NewDocument(text)
+            if err != nil {
+                b.Fatal(err)
+            }
+            insert := Edit{Start: at.offset, End: at.offset, Text: "0"}
+            remove := Edit{Start: at.offset, End: at.offset + 1, Text: ""}
+            b.ReportAllocs()
+            b.ResetTimer()
+
+            for i := 0; i < b.N; i++ {
+                edit := insert
+                if i % 2 == 1 {
+                    edit = remove
+                }
+                if _, err := document.Edit(edit); err != nil {
+                    b.Fatal(err)
+                }
+            }
+        })
+    }
+}
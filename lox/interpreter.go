@@ -1,31 +1,65 @@
 package lox
 
-import (
-	"errors"
-	"fmt"
+import "errors"
+
+// Hook observes the interpreter while it walks an expression tree.
+// Enter is called before an expression is evaluated and Exit once its value is
+// known. depth is the number of enclosing expressions still being evaluated,
+// so the root expression has depth 0.
+type Hook interface {
+    Enter(expr Expr, depth int)
+    Exit(expr Expr, value Value, err error, depth int)
+}
+
+const (
+    lhsErrorMessage = "error evaluating the lhs of binary expression"
+    rhsErrorMessage = "error evaluating the rhs of binary expression"
+    unaryErrorMessage = "error evaluating unary expression"
+    notANumberMessage = "not a number"
 )
 
+type interpreter struct {
+    hook Hook
+    depth int
+}
+
 func Interpret(expr Expr) (any, error) {
-    value, err := evaluate(expr)
-    return value, err
+    return InterpretWithHook(expr, nil)
 }
 
-func evaluate(expr Expr) (any, error) {
-    switch expr.(type) {
-        case Literal:
-            literal, _ := expr.(Literal)
-            return literal.value, nil
-        case Grouping:
-            grouping, _ := expr.(Grouping)
-            return evaluate(grouping.expression)
-        case Unary:
-            unary, _ := expr.(Unary)
-            return evaluateUnary(unary)
-        case Binary:
-            binary, _ := expr.(Binary)
-            return evaluateBinary(binary)
+// InterpretWithHook evaluates expr like Interpret, reporting every evaluation
+// step to hook. A nil hook is allowed.
+func InterpretWithHook(expr Expr, hook Hook) (any, error) {
+    interpreter := interpreter{hook: hook}
+    value, err := interpreter.evaluate(expr)
+    if err != nil {
+        return nil, err
     }
-    return nil, errors.New("error occurred while evaluating")
+    return value.Any(), nil
+}
+
+var _ ExprVisitor[Value] = (*interpreter)(nil)
+
+func (interpreter *interpreter) evaluate(expr Expr) (Value, error) {
+    if interpreter.hook == nil {
+        return VisitExpr[Value](expr, interpreter)
+    }
+
+    interpreter.hook.Enter(expr, interpreter.depth)
+    interpreter.depth++
+    value, err := VisitExpr[Value](expr, interpreter)
+    interpreter.depth--
+    interpreter.hook.Exit(expr, value, err, interpreter.depth)
+
+    return value, err
+}
+
+func (interpreter *interpreter) VisitLiteral(literal Literal) (Value, error) {
+    return literal.value, nil
+}
+
+func (interpreter *interpreter) VisitGrouping(grouping Grouping) (Value, error) {
+    return interpreter.evaluate(grouping.expression)
 }
 
 // If both lhs and rhs are numbers, then all operations are valid
@@ -34,83 +68,74 @@ func evaluate(expr Expr) (any, error) {
 // otherwise, "return not a number"
 // If division by zero, return inf (follow ecmaScript)
 // TODO: require heavy testing
-func evaluateBinary(binary Binary) (any, error) {
-    left, err := evaluate(binary.left)
+func (interpreter *interpreter) VisitBinary(binary Binary) (Value, error) {
+    left, err := interpreter.evaluate(binary.left)
     if err != nil {
-        return nil, errors.New("error evaluating the lhs of binary expression")
+        return Value{}, errors.New(lhsErrorMessage)
     }
-    right, err := evaluate(binary.right)
+    right, err := interpreter.evaluate(binary.right)
     if err != nil {
-        return nil, errors.New("error evaluating the rhs of binary expression")
+        return Value{}, errors.New(rhsErrorMessage)
     }
 
-    leftNumber, isLhsFloat := left.(float64)
-    rightNumber, isRhsFloat := right.(float64)
+    leftNumber := left.AsNumber()
+    rightNumber := right.AsNumber()
 
-    if isLhsFloat && isRhsFloat {
+    if left.IsNumber() && right.IsNumber() {
         switch binary.operator.tokenType {
             case MINUS:
-                return leftNumber - rightNumber, nil
+                return NumberVal(leftNumber - rightNumber), nil
             case SLASH:
-                return leftNumber / rightNumber, nil
+                return NumberVal(leftNumber / rightNumber), nil
             case STAR:
-                return leftNumber * rightNumber, nil
+                return NumberVal(leftNumber * rightNumber), nil
             case PLUS:
-                return leftNumber + rightNumber, nil
+                return NumberVal(leftNumber + rightNumber), nil
             case GREATER:
-                return leftNumber > rightNumber, nil
+                return BoolVal(leftNumber > rightNumber), nil
             case GREATER_EQUAL:
-                return leftNumber >= rightNumber, nil
+                return BoolVal(leftNumber >= rightNumber), nil
             case LESS:
-                return leftNumber < rightNumber, nil
+                return BoolVal(leftNumber < rightNumber), nil
             case LESS_EQUAL:
-                return leftNumber <= rightNumber, nil
+                return BoolVal(leftNumber <= rightNumber), nil
             case BANG_EQUAL:
-                return leftNumber != rightNumber, nil
+                return BoolVal(leftNumber != rightNumber), nil
             case EQUAL_EQUAL:
-                return leftNumber == rightNumber, nil
+                return BoolVal(leftNumber == rightNumber), nil
         }
     } else {
         switch binary.operator.tokenType {
             case BANG_EQUAL:
-                return isTruthy(left) != isTruthy(right), nil
+                return BoolVal(left.isTruthy() != right.isTruthy()), nil
             case EQUAL_EQUAL:
-                return isTruthy(left) == isTruthy(right), nil
+                return BoolVal(left.isTruthy() == right.isTruthy()), nil
             case PLUS:
-                return fmt.Sprintf("%v%v", left, right), nil
+                return StringVal(left.format() + right.format()), nil
         }
     }
-    fmt.Println("invalid")
-    return nil, errors.New("not a number")
+    return Value{}, errors.New(notANumberMessage)
 }
 
-func evaluateUnary(unary Unary) (any, error) {
-    right, err := evaluate(unary.right)
+func (interpreter *interpreter) VisitUnary(unary Unary) (Value, error) {
+    right, err := interpreter.evaluate(unary.right)
 
     if err != nil {
-        return nil, errors.New("error evaluating unary expression")
+        return Value{}, errors.New(unaryErrorMessage)
     }
 
     if unary.operator.tokenType == MINUS {
-        number, ok := right.(float64)
-
-        if !ok {
-            return nil, errors.New("not a number")
+        if !right.IsNumber() {
+            return Value{}, errors.New(notANumberMessage)
         }
-        return -number, nil
+        return NumberVal(-right.AsNumber()), nil
     } else if unary.operator.tokenType == BANG {
-        return !isTruthy(right), nil
+        return BoolVal(!right.isTruthy()), nil
     }
-    return nil, errors.New("error evaluating unary expression")
+    return Value{}, errors.New(unaryErrorMessage)
 }
 
-func isTruthy(expr any) bool {
-    if expr == nil {
-        return false
-    }
-    val, ok := expr.(bool)
-    if !ok {
-        return true
-    }
-    return val
+// Stringify formats a runtime value the way Lox prints it.
+func Stringify(value any) string {
+    return ValueOf(value).String()
 }
@@ -1,10 +1,40 @@
 package lox
 
 import (
+	"fmt"
 	"math"
+	"strings"
 	"testing"
 )
 
+// interpretBothPaths evaluates expr with the tree-walker and with
+// CompileClosures, failing the test if they disagree.
+func interpretBothPaths(t *testing.T, expr Expr) (any, error) {
+    result, err := Interpret(expr)
+    closureResult, closureErr := CompileClosures(expr)()
+    assertSameResult(t, closureResult, closureErr, result, err)
+
+    return result, err
+}
+
+// assertSameResult fails the test unless an engine's result and error are
+// those the tree-walker gave, for tests checking that engines agree.
+func assertSameResult(t *testing.T, result any, err error, expected any, expectedErr error) {
+    t.Helper()
+
+    if result != expected && !(isNaN(result) && isNaN(expected)) {
+        t.Errorf("Incorrect result.\nresult  :%v\nexpected:%v\n", result, expected)
+    }
+    if (err == nil) != (expectedErr == nil) || (err != nil && err.Error() != expectedErr.Error()) {
+        t.Errorf("Incorrect error.\nresult  :%v\nexpected:%v\n", err, expectedErr)
+    }
+}
+
+func isNaN(value any) bool {
+    number, ok := value.(float64)
+    return ok && math.IsNaN(number)
+}
+
 func TestInterpreterArithmetic(t *testing.T) {
     tests := []struct {
         name string
@@ -14,92 +44,92 @@ func TestInterpreterArithmetic(t *testing.T) {
         {
             name: "low-precision flaoting point addition: 1.1 + 2.2",
             input: Binary {
-                right: Literal{float64(1.1)},
-                operator: Token{PLUS, "+", "", 1},
-                left: Literal{float64(2.2)},
+                right: Literal{value: NumberVal(1.1)},
+                operator: Token{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+                left: Literal{value: NumberVal(2.2)},
             },
             expected: float64(1.1) + float64(2.2),
         },
         {
             name: "high-precision floating point addition: 1.10000001 + 2.24354352",
             input: Binary {
-                right: Literal{float64(1.10000001)},
-                operator: Token{PLUS, "+", "", 1},
-                left: Literal{float64(2.24354352)},
+                right: Literal{value: NumberVal(1.10000001)},
+                operator: Token{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+                left: Literal{value: NumberVal(2.24354352)},
             },
             expected: float64(1.10000001) + float64(2.24354352),
         },
         {
             name: "low-precision flaoting point positive difference: 2.2 - 1.1",
             input: Binary {
-                left: Literal{float64(2.2)},
-                operator: Token{MINUS, "-", "", 1},
-                right: Literal{float64(1.1)},
+                left: Literal{value: NumberVal(2.2)},
+                operator: Token{tokenType: MINUS, lexeme: "-", literal: StringVal(""), line: 1},
+                right: Literal{value: NumberVal(1.1)},
             },
             expected: float64(2.2) - float64(1.1),
         },
         {
             name: "high-precision floating point positive difference: 2.24354352 - 1.10000001",
             input: Binary {
-                left: Literal{float64(2.24354352)},
-                operator: Token{MINUS, "-", "", 1},
-                right: Literal{float64(1.10000001)},
+                left: Literal{value: NumberVal(2.24354352)},
+                operator: Token{tokenType: MINUS, lexeme: "-", literal: StringVal(""), line: 1},
+                right: Literal{value: NumberVal(1.10000001)},
             },
             expected: float64(2.24354352) - float64(1.10000001),
         },
         {
             name: "low-precision flaoting point negative difference: 1.1 - 2.2",
             input: Binary {
-                right: Literal{float64(2.2)},
-                operator: Token{MINUS, "-", "", 1},
-                left: Literal{float64(1.1)},
+                right: Literal{value: NumberVal(2.2)},
+                operator: Token{tokenType: MINUS, lexeme: "-", literal: StringVal(""), line: 1},
+                left: Literal{value: NumberVal(1.1)},
             },
             expected: float64(1.1) - float64(2.2),
         },
         {
             name: "high-precision floating point negative difference: 1.10000001 - 2.24354352",
             input: Binary {
-                right: Literal{float64(2.24354352)},
-                operator: Token{MINUS, "-", "", 1},
-                left: Literal{float64(1.10000001)},
+                right: Literal{value: NumberVal(2.24354352)},
+                operator: Token{tokenType: MINUS, lexeme: "-", literal: StringVal(""), line: 1},
+                left: Literal{value: NumberVal(1.10000001)},
             },
             expected: float64(1.10000001) - float64(2.24354352),
         },
         {
             name: "low-precision flaoting point multiplication: 3.3 * 2.2",
             input: Binary {
-                left: Literal{float64(3.3)},
-                operator: Token{STAR, "*", "", 1},
-                right: Literal{float64(2.2)},
+                left: Literal{value: NumberVal(3.3)},
+                operator: Token{tokenType: STAR, lexeme: "*", literal: StringVal(""), line: 1},
+                right: Literal{value: NumberVal(2.2)},
             },
             expected: float64(3.3) * float64(2.2),
         },
         {
             name: "high-precision floating point multiplication: 1.10000001 * 2.24354352",
             input: Binary {
-                right: Literal{float64(1.10000001)},
-                operator: Token{STAR, "*", "", 1},
-                left: Literal{float64(2.24354352)},
+                right: Literal{value: NumberVal(1.10000001)},
+                operator: Token{tokenType: STAR, lexeme: "*", literal: StringVal(""), line: 1},
+                left: Literal{value: NumberVal(2.24354352)},
             },
             expected: float64(1.10000001) * float64(2.24354352),
         },
         {
             name: "multiplication overflows to +Inf: 1.7976931348623157e+308 * 1.5",
             input: Binary {
-                right: Literal{float64(1.7976931348623157e+308)},
-                operator: Token{STAR, "*", "", 1},
-                left: Literal{float64(1.5)},
+                right: Literal{value: NumberVal(1.7976931348623157e+308)},
+                operator: Token{tokenType: STAR, lexeme: "*", literal: StringVal(""), line: 1},
+                left: Literal{value: NumberVal(1.5)},
             },
             expected: math.Inf(1),
         },
         {
             name: "multiplication underflows to -Inf: 1.7976931348623157e+308 * -2",
             input: Binary {
-                left: Literal{float64(1.7976931348623157e+308)},
-                operator: Token{STAR, "*", "", 1},
+                left: Literal{value: NumberVal(1.7976931348623157e+308)},
+                operator: Token{tokenType: STAR, lexeme: "*", literal: StringVal(""), line: 1},
                 right: Unary{
-                    operator: Token{MINUS, "-", "", 1},
-                    right: Literal{float64(2)},
+                    operator: Token{tokenType: MINUS, lexeme: "-", literal: StringVal(""), line: 1},
+                    right: Literal{value: NumberVal(2)},
                 },
             },
             expected: math.Inf(-1),
@@ -107,27 +137,27 @@ func TestInterpreterArithmetic(t *testing.T) {
         {
             name: "low-precision flaoting point division: 3.3 / 2.2",
             input: Binary {
-                left: Literal{float64(3.3)},
-                operator: Token{SLASH, "/", "", 1},
-                right: Literal{float64(2.2)},
+                left: Literal{value: NumberVal(3.3)},
+                operator: Token{tokenType: SLASH, lexeme: "/", literal: StringVal(""), line: 1},
+                right: Literal{value: NumberVal(2.2)},
             },
             expected: float64(3.3) / float64(2.2),
         },
         {
             name: "high-precision floating point division: 1.10000001 / 2.24354352",
             input: Binary {
-                left: Literal{float64(1.10000001)},
-                operator: Token{SLASH, "/", "", 1},
-                right: Literal{float64(2.24354352)},
+                left: Literal{value: NumberVal(1.10000001)},
+                operator: Token{tokenType: SLASH, lexeme: "/", literal: StringVal(""), line: 1},
+                right: Literal{value: NumberVal(2.24354352)},
             },
             expected: float64(1.10000001) / float64(2.24354352),
         },
         {
             name: "division by zero: 11.0 / 0",
             input: Binary {
-                left: Literal{float64(11.0)},
-                operator: Token{SLASH, "/", "", 1},
-                right: Literal{float64(0.0)},
+                left: Literal{value: NumberVal(11.0)},
+                operator: Token{tokenType: SLASH, lexeme: "/", literal: StringVal(""), line: 1},
+                right: Literal{value: NumberVal(0.0)},
             },
             expected: math.Inf(1),
         },
@@ -136,12 +166,12 @@ func TestInterpreterArithmetic(t *testing.T) {
             input: Grouping{
                 expression: Binary{
                     left: Binary{
-                        left: Literal{1.1},
-                        operator: Token{PLUS, "+", "", 1},
-                        right: Literal{2.0},
+                        left: Literal{value: NumberVal(1.1)},
+                        operator: Token{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+                        right: Literal{value: NumberVal(2.0)},
                     },
-                    operator: Token{MINUS, "-", "", 1},
-                    right: Literal{10.0},
+                    operator: Token{tokenType: MINUS, lexeme: "-", literal: StringVal(""), line: 1},
+                    right: Literal{value: NumberVal(10.0)},
                 },
             },
             expected: (1.1 + 2 - 10),
@@ -150,9 +180,9 @@ func TestInterpreterArithmetic(t *testing.T) {
             name: "grouped expression binary: (1.1 + 2)",
             input: Grouping{
                 expression: Binary{
-                    left: Literal{1.1},
-                    operator: Token{PLUS, "+", "", 1},
-                    right: Literal{2.0},
+                    left: Literal{value: NumberVal(1.1)},
+                    operator: Token{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+                    right: Literal{value: NumberVal(2.0)},
                 },
             },
             expected: (1.1 + 2.0),
@@ -164,19 +194,19 @@ func TestInterpreterArithmetic(t *testing.T) {
                     left: Grouping{
                         expression: Binary{
                             left: Binary{
-                                left: Literal{1.1},
-                                operator: Token{PLUS, "+", "", 1},
-                                right: Literal{2.0},
+                                left: Literal{value: NumberVal(1.1)},
+                                operator: Token{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+                                right: Literal{value: NumberVal(2.0)},
                             },
-                            operator: Token{MINUS, "-", "", 1},
-                            right: Literal{10.0},
+                            operator: Token{tokenType: MINUS, lexeme: "-", literal: StringVal(""), line: 1},
+                            right: Literal{value: NumberVal(10.0)},
                         },
                     },
-                    operator: Token{STAR, "*", "", 1},
-                    right: Literal{1.1},
+                    operator: Token{tokenType: STAR, lexeme: "*", literal: StringVal(""), line: 1},
+                    right: Literal{value: NumberVal(1.1)},
                 },
-                operator: Token{SLASH, "/", "", 1},
-                right: Literal{2.242} ,
+                operator: Token{tokenType: SLASH, lexeme: "/", literal: StringVal(""), line: 1},
+                right: Literal{value: NumberVal(2.242)} ,
             },
             expected: (1.1 + 2.0 - 10.0) * 1.1 / 2.242,
         },
@@ -184,7 +214,7 @@ func TestInterpreterArithmetic(t *testing.T) {
 
     for _, test := range tests {
         t.Run(test.name, func(t *testing.T) {
-            result, err := Interpret(test.input)
+            result, err := interpretBothPaths(t, test.input)
             if err != nil {
                 t.Errorf("no error expected\n")
             }
@@ -207,54 +237,54 @@ func TestStringConcat(t *testing.T) {
         {
             name: `"hello" + ", world!"`,
             input: Binary{
-                left: Literal{"hello"},
-                operator: Token{PLUS, "+", "", 1},
-                right: Literal{", world!"},
+                left: Literal{value: StringVal("hello")},
+                operator: Token{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+                right: Literal{value: StringVal(", world!")},
             },
             expected: "hello, world!",
         },
         {
             name: `"" + ", world!"`,
             input: Binary{
-                left: Literal{""},
-                operator: Token{PLUS, "+", "", 1},
-                right: Literal{", world!"},
+                left: Literal{value: StringVal("")},
+                operator: Token{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+                right: Literal{value: StringVal(", world!")},
             },
             expected: ", world!",
         },
         {
             name: `"hello" + ""`,
             input: Binary{
-                left: Literal{"hello"},
-                operator: Token{PLUS, "+", "", 1},
-                right: Literal{""},
+                left: Literal{value: StringVal("hello")},
+                operator: Token{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+                right: Literal{value: StringVal("")},
             },
             expected: "hello",
         },
         {
             name: `"" + ""`,
             input: Binary{
-                left: Literal{""},
-                operator: Token{PLUS, "+", "", 1},
-                right: Literal{""},
+                left: Literal{value: StringVal("")},
+                operator: Token{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+                right: Literal{value: StringVal("")},
             },
             expected: "",
         },
         {
             name: `"" + 1`,
             input: Binary{
-                left: Literal{""},
-                operator: Token{PLUS, "+", "", 1},
-                right: Literal{1.0},
+                left: Literal{value: StringVal("")},
+                operator: Token{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+                right: Literal{value: NumberVal(1.0)},
             },
             expected: "1",
         },
         {
             name: `1 + ""`,
             input: Binary{
-                left: Literal{1.0},
-                operator: Token{PLUS, "+", "", 1},
-                right: Literal{""},
+                left: Literal{value: NumberVal(1.0)},
+                operator: Token{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+                right: Literal{value: StringVal("")},
             },
             expected: "1",
         }, 
@@ -262,12 +292,12 @@ func TestStringConcat(t *testing.T) {
             name: `1 + 1 + "1"`,
             input: Binary{
                 left: Binary{
-                    left: Literal{1.0},
-                    operator: Token{PLUS, "+", "", 1},
-                    right: Literal{1.0},
+                    left: Literal{value: NumberVal(1.0)},
+                    operator: Token{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+                    right: Literal{value: NumberVal(1.0)},
                 },
-                operator: Token{PLUS, "+", "", 1},
-                right: Literal{"1"},
+                operator: Token{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+                right: Literal{value: StringVal("1")},
             },
             expected: "21",
         }, 
@@ -275,12 +305,12 @@ func TestStringConcat(t *testing.T) {
             name: `"1" + 1 + 1`,
             input: Binary{
                 left: Binary{
-                    left: Literal{"1"},
-                    operator: Token{PLUS, "+", "", 1},
-                    right: Literal{1.0},
+                    left: Literal{value: StringVal("1")},
+                    operator: Token{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+                    right: Literal{value: NumberVal(1.0)},
                 },
-                operator: Token{PLUS, "+", "", 1},
-                right: Literal{1.0},
+                operator: Token{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+                right: Literal{value: NumberVal(1.0)},
             },
             expected: "111",
         }, 
@@ -288,7 +318,7 @@ func TestStringConcat(t *testing.T) {
 
     for _, test := range tests {
         t.Run(test.name, func(t *testing.T) {
-            result, err := Interpret(test.input)
+            result, err := interpretBothPaths(t, test.input)
 
             if err != nil {
                 t.Errorf("no error expected\n")
@@ -312,45 +342,45 @@ func TestLogicOperator(t *testing.T) {
         {
             name: "true == false",
             input: Binary{
-                left: Literal{true},
-                operator: Token{EQUAL_EQUAL, "==", "", 1},
-                right: Literal{false},
+                left: Literal{value: BoolVal(true)},
+                operator: Token{tokenType: EQUAL_EQUAL, lexeme: "==", literal: StringVal(""), line: 1},
+                right: Literal{value: BoolVal(false)},
             },
             expected: false,
         },
         {
             name: "true == true",
             input: Binary{
-                left: Literal{true},
-                operator: Token{EQUAL_EQUAL, "==", "", 1},
-                right: Literal{true},
+                left: Literal{value: BoolVal(true)},
+                operator: Token{tokenType: EQUAL_EQUAL, lexeme: "==", literal: StringVal(""), line: 1},
+                right: Literal{value: BoolVal(true)},
             },
             expected: true,
         },
         {
             name: "false == false",
             input: Binary{
-                left: Literal{false},
-                operator: Token{EQUAL_EQUAL, "==", "", 1},
-                right: Literal{false},
+                left: Literal{value: BoolVal(false)},
+                operator: Token{tokenType: EQUAL_EQUAL, lexeme: "==", literal: StringVal(""), line: 1},
+                right: Literal{value: BoolVal(false)},
             },
             expected: true,
         },
         {
             name: "false != false",
             input: Binary{
-                left: Literal{false},
-                operator: Token{BANG_EQUAL, "!=", "", 1},
-                right: Literal{false},
+                left: Literal{value: BoolVal(false)},
+                operator: Token{tokenType: BANG_EQUAL, lexeme: "!=", literal: StringVal(""), line: 1},
+                right: Literal{value: BoolVal(false)},
             },
             expected: false,
         },
         {
             name: "true != false",
             input: Binary{
-                left: Literal{true},
-                operator: Token{BANG_EQUAL, "!=", "", 1},
-                right: Literal{false},
+                left: Literal{value: BoolVal(true)},
+                operator: Token{tokenType: BANG_EQUAL, lexeme: "!=", literal: StringVal(""), line: 1},
+                right: Literal{value: BoolVal(false)},
             },
             expected: true,
         },
@@ -358,93 +388,93 @@ func TestLogicOperator(t *testing.T) {
             name: "!false == true",
             input: Binary{
                 left: Unary{
-                    operator: Token{BANG, "!", "", 1},
-                    right: Literal{false},
+                    operator: Token{tokenType: BANG, lexeme: "!", literal: StringVal(""), line: 1},
+                    right: Literal{value: BoolVal(false)},
 
                 },
-                operator: Token{EQUAL_EQUAL, "==", "", 1},
-                right: Literal{true},
+                operator: Token{tokenType: EQUAL_EQUAL, lexeme: "==", literal: StringVal(""), line: 1},
+                right: Literal{value: BoolVal(true)},
             },
             expected: true,
         },
         {
             name: "9.5 == 9.5",
             input: Binary{
-                left: Literal{9.5},
-                operator: Token{EQUAL_EQUAL, "==", "", 1},
-                right: Literal{9.5},
+                left: Literal{value: NumberVal(9.5)},
+                operator: Token{tokenType: EQUAL_EQUAL, lexeme: "==", literal: StringVal(""), line: 1},
+                right: Literal{value: NumberVal(9.5)},
             },
             expected: true,
         },
         {
             name: "1 == 2",
             input: Binary{
-                left: Literal{1.0},
-                operator: Token{EQUAL_EQUAL, "==", "", 1},
-                right: Literal{2.0},
+                left: Literal{value: NumberVal(1.0)},
+                operator: Token{tokenType: EQUAL_EQUAL, lexeme: "==", literal: StringVal(""), line: 1},
+                right: Literal{value: NumberVal(2.0)},
             },
             expected: false,
         },
         {
             name: "1 < 2",
             input: Binary{
-                left: Literal{1.0},
-                operator: Token{LESS, "<", "", 1},
-                right: Literal{2.0},
+                left: Literal{value: NumberVal(1.0)},
+                operator: Token{tokenType: LESS, lexeme: "<", literal: StringVal(""), line: 1},
+                right: Literal{value: NumberVal(2.0)},
             },
             expected: true,
         },
         {
             name: "1 > 2",
             input: Binary{
-                left: Literal{1.0},
-                operator: Token{GREATER, ">", "", 1},
-                right: Literal{2.0},
+                left: Literal{value: NumberVal(1.0)},
+                operator: Token{tokenType: GREATER, lexeme: ">", literal: StringVal(""), line: 1},
+                right: Literal{value: NumberVal(2.0)},
             },
             expected: false,
         },
         {
             name: "1 <= 2",
             input: Binary{
-                left: Literal{1.0},
-                operator: Token{LESS_EQUAL, "<=", "", 1},
-                right: Literal{2.0},
+                left: Literal{value: NumberVal(1.0)},
+                operator: Token{tokenType: LESS_EQUAL, lexeme: "<=", literal: StringVal(""), line: 1},
+                right: Literal{value: NumberVal(2.0)},
             },
             expected: true,
         },
         {
             name: "1 >= 2",
             input: Binary{
-                left: Literal{1.0},
-                operator: Token{GREATER_EQUAL, ">=", "", 1},
-                right: Literal{2.0},
+                left: Literal{value: NumberVal(1.0)},
+                operator: Token{tokenType: GREATER_EQUAL, lexeme: ">=", literal: StringVal(""), line: 1},
+                right: Literal{value: NumberVal(2.0)},
             },
             expected: false,
         },
         {
             name: "2 <= 2",
             input: Binary{
-                left: Literal{2.0},
-                operator: Token{LESS_EQUAL, "<=", "", 1},
-                right: Literal{2.0},
+                left: Literal{value: NumberVal(2.0)},
+                operator: Token{tokenType: LESS_EQUAL, lexeme: "<=", literal: StringVal(""), line: 1},
+                right: Literal{value: NumberVal(2.0)},
             },
             expected: true,
         },
         {
             name: "2 >= 2",
             input: Binary{
-                left: Literal{2.0},
-                operator: Token{GREATER_EQUAL, ">=", "", 1},
-                right: Literal{2.0},
+                left: Literal{value: NumberVal(2.0)},
+                operator: Token{tokenType: GREATER_EQUAL, lexeme: ">=", literal: StringVal(""), line: 1},
+                right: Literal{value: NumberVal(2.0)},
             },
             expected: true,
         },
         {
             name: `"string" == true`,
             input: Binary{
-                left: Literal{"string"},
-                operator: Token{EQUAL_EQUAL, "==", "", 1},
-                right: Literal{true},
+                left: Literal{value: StringVal("string")},
+                operator: Token{tokenType: EQUAL_EQUAL, lexeme: "==", literal: StringVal(""), line: 1},
+                right: Literal{value: BoolVal(true)},
             },
             expected: true,
         },
@@ -454,7 +484,7 @@ func TestLogicOperator(t *testing.T) {
 
     for _, test := range tests {
         t.Run(test.name, func(t *testing.T) {
-            result, err := Interpret(test.input)
+            result, err := interpretBothPaths(t, test.input)
             if err != nil {
                 t.Errorf("no error expected\n")
             }
@@ -465,3 +495,65 @@ func TestLogicOperator(t *testing.T) {
         })
     }
 }
+
+type recordingHook struct {
+    events []string
+}
+
+func (hook *recordingHook) Enter(expr Expr, depth int) {
+    hook.events = append(hook.events, fmt.Sprintf("enter %d %s", depth, expr.Print()))
+}
+
+func (hook *recordingHook) Exit(expr Expr, value Value, err error, depth int) {
+    hook.events = append(hook.events, fmt.Sprintf("exit %d %v", depth, value))
+}
+
+func TestInterpretWithHook(t *testing.T) {
+    input := Binary{
+        left: Unary{
+            operator: Token{tokenType: MINUS, lexeme: "-", literal: StringVal(""), line: 1},
+            right: Literal{value: NumberVal(2.0)},
+        },
+        operator: Token{tokenType: STAR, lexeme: "*", literal: StringVal(""), line: 1},
+        right: Grouping{expression: Literal{value: NumberVal(3.0)}},
+    }
+    expected := []string{
+        "enter 0 (* (- 2) (group 3))",
+        "enter 1 (- 2)",
+        "enter 2 2",
+        "exit 2 2",
+        "exit 1 -2",
+        "enter 1 (group 3)",
+        "enter 2 3",
+        "exit 2 3",
+        "exit 1 3",
+        "exit 0 -6",
+    }
+
+    hook := &recordingHook{}
+    result, err := InterpretWithHook(input, hook)
+    if err != nil {
+        t.Errorf("no error expected\n")
+    }
+    if result != -6.0 {
+        t.Errorf("Incorrect result.\nresult  :%v\nexpected:%v\n", result, -6.0)
+    }
+
+    if strings.Join(hook.events, "\n") != strings.Join(expected, "\n") {
+        t.Errorf("Incorrect events.\nresult  :\n%s\nexpected:\n%s\n", strings.Join(hook.events, "\n"), strings.Join(expected, "\n"))
+    }
+}
+
+func BenchmarkInterpret(b *testing.B) {
+    for _, input := range benchmarkInputs {
+        b.Run(input.name, func(b *testing.B) {
+            expression := parseSource(b, input.source)
+            b.ReportAllocs()
+            b.ResetTimer()
+
+            for i := 0; i < b.N; i++ {
+                Interpret(expression)
+            }
+        })
+    }
+}
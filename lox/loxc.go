@@ -0,0 +1,336 @@
+package lox
+
+import (
+    "bytes"
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "hash/crc32"
+    "math"
+)
+
+// A .loxc file holds one compiled chunk:
+//
+//	magic     4 bytes  "\x1bLox"
+//	version   uint16   LoxcVersion
+//	checksum  uint32   CRC-32 (IEEE) of the body
+//	body:
+//	  code           uvarint length, then the bytes
+//	  line table     uvarint run count, then (uvarint line, uvarint length) runs
+//	  constants      uvarint count, then a tag byte and payload per constant
+//	  error messages uvarint count, then (uvarint offset, string) pairs
+//
+// Strings are a uvarint length followed by UTF-8 bytes, numbers are IEEE 754
+// bits in little endian. Chunks do not contain nested functions yet, so there
+// are no function prototypes in the format.
+const LoxcVersion = 1
+
+var loxcMagic = []byte("\x1bLox")
+
+const loxcHeaderSize = 10
+
+const (
+    constantNumber byte = iota
+    constantString
+)
+
+var (
+    ErrNotLoxc = errors.New("loxc: not a compiled lox file")
+    ErrLoxcChecksum = errors.New("loxc: checksum mismatch, file is corrupt")
+)
+
+// IsLoxc reports whether data starts with the .loxc magic number.
+func IsLoxc(data []byte) bool {
+    return bytes.HasPrefix(data, loxcMagic)
+}
+
+// MarshalBinary encodes the chunk in the .loxc format.
+func (chunk *Chunk) MarshalBinary() ([]byte, error) {
+    var body []byte
+
+    body = binary.AppendUvarint(body, uint64(len(chunk.code)))
+    body = append(body, chunk.code...)
+
+    var runs [][2]int
+    for _, line := range chunk.lines {
+        if len(runs) > 0 && runs[len(runs) - 1][0] == line {
+            runs[len(runs) - 1][1]++
+        } else {
+            runs = append(runs, [2]int{line, 1})
+        }
+    }
+    body = binary.AppendUvarint(body, uint64(len(runs)))
+    for _, run := range runs {
+        body = binary.AppendUvarint(body, uint64(run[0]))
+        body = binary.AppendUvarint(body, uint64(run[1]))
+    }
+
+    body = binary.AppendUvarint(body, uint64(len(chunk.constants)))
+    for _, constant := range chunk.constants {
+        switch constant.Kind() {
+        case NumberValue:
+            body = append(body, constantNumber)
+            body = binary.LittleEndian.AppendUint64(body, math.Float64bits(constant.AsNumber()))
+        case StringValue:
+            body = append(body, constantString)
+            body = appendString(body, constant.AsString())
+        default:
+            return nil, fmt.Errorf("loxc: cannot encode constant %v", constant)
+        }
+    }
+
+    body = binary.AppendUvarint(body, uint64(len(chunk.errorMessages)))
+    for offset := 0; offset < len(chunk.code); offset++ {
+        if message, ok := chunk.errorMessages[offset]; ok {
+            body = binary.AppendUvarint(body, uint64(offset))
+            body = appendString(body, message)
+        }
+    }
+
+    data := make([]byte, 0, loxcHeaderSize + len(body))
+    data = append(data, loxcMagic...)
+    data = binary.LittleEndian.AppendUint16(data, LoxcVersion)
+    data = binary.LittleEndian.AppendUint32(data, crc32.ChecksumIEEE(body))
+    data = append(data, body...)
+
+    return data, nil
+}
+
+func appendString(data []byte, s string) []byte {
+    data = binary.AppendUvarint(data, uint64(len(s)))
+    return append(data, s...)
+}
+
+// UnmarshalBinary decodes a chunk in the .loxc format. The chunk is verified
+// before it is returned, so the VM can run it without further checks.
+func (chunk *Chunk) UnmarshalBinary(data []byte) error {
+    if !IsLoxc(data) {
+        return ErrNotLoxc
+    }
+    if len(data) < loxcHeaderSize {
+        return errors.New("loxc: truncated header")
+    }
+
+    version := binary.LittleEndian.Uint16(data[4:])
+    if version != LoxcVersion {
+        return fmt.Errorf("loxc: unsupported version %d, this glox reads version %d", version, LoxcVersion)
+    }
+
+    body := data[loxcHeaderSize:]
+    if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(data[6:]) {
+        return ErrLoxcChecksum
+    }
+
+    decoded, err := decodeChunk(&loxcReader{data: body})
+    if err != nil {
+        return err
+    }
+    if err := decoded.verify(); err != nil {
+        return err
+    }
+
+    *chunk = *decoded
+    return nil
+}
+
+type loxcReader struct {
+    data []byte
+    offset int
+}
+
+var errLoxcTruncated = errors.New("loxc: unexpected end of data")
+
+func (reader *loxcReader) uvarint() (int, error) {
+    value, n := binary.Uvarint(reader.data[reader.offset:])
+    if n <= 0 || value > math.MaxInt32 {
+        return 0, errLoxcTruncated
+    }
+    reader.offset += n
+    return int(value), nil
+}
+
+func (reader *loxcReader) bytes(n int) ([]byte, error) {
+    if n > len(reader.data) - reader.offset {
+        return nil, errLoxcTruncated
+    }
+    result := reader.data[reader.offset:reader.offset + n]
+    reader.offset += n
+    return result, nil
+}
+
+func (reader *loxcReader) string() (string, error) {
+    length, err := reader.uvarint()
+    if err != nil {
+        return "", err
+    }
+    b, err := reader.bytes(length)
+    return string(b), err
+}
+
+func decodeChunk(reader *loxcReader) (*Chunk, error) {
+    chunk := &Chunk{errorMessages: map[int]string{}}
+
+    codeLength, err := reader.uvarint()
+    if err != nil {
+        return nil, err
+    }
+    code, err := reader.bytes(codeLength)
+    if err != nil {
+        return nil, err
+    }
+    chunk.code = bytes.Clone(code)
+
+    runCount, err := reader.uvarint()
+    if err != nil {
+        return nil, err
+    }
+    for i := 0; i < runCount; i++ {
+        line, err := reader.uvarint()
+        if err != nil {
+            return nil, err
+        }
+        length, err := reader.uvarint()
+        if err != nil {
+            return nil, err
+        }
+        if length > len(chunk.code) - len(chunk.lines) {
+            return nil, errors.New("loxc: line table is longer than the code")
+        }
+        for j := 0; j < length; j++ {
+            chunk.lines = append(chunk.lines, line)
+        }
+    }
+
+    constantCount, err := reader.uvarint()
+    if err != nil {
+        return nil, err
+    }
+    for i := 0; i < constantCount; i++ {
+        tag, err := reader.bytes(1)
+        if err != nil {
+            return nil, err
+        }
+
+        switch tag[0] {
+        case constantNumber:
+            bits, err := reader.bytes(8)
+            if err != nil {
+                return nil, err
+            }
+            chunk.constants = append(chunk.constants, NumberVal(math.Float64frombits(binary.LittleEndian.Uint64(bits))))
+        case constantString:
+            s, err := reader.string()
+            if err != nil {
+                return nil, err
+            }
+            chunk.constants = append(chunk.constants, StringVal(s))
+        default:
+            return nil, fmt.Errorf("loxc: unknown constant tag %d", tag[0])
+        }
+    }
+
+    messageCount, err := reader.uvarint()
+    if err != nil {
+        return nil, err
+    }
+    for i := 0; i < messageCount; i++ {
+        offset, err := reader.uvarint()
+        if err != nil {
+            return nil, err
+        }
+        message, err := reader.string()
+        if err != nil {
+            return nil, err
+        }
+        chunk.errorMessages[offset] = message
+    }
+
+    if reader.offset != len(reader.data) {
+        return nil, errors.New("loxc: trailing data after chunk")
+    }
+
+    return chunk, nil
+}
+
+// stackEffects gives the number of values each instruction pops and pushes.
+var stackEffects = map[OpCode][2]int {
+    OP_CONSTANT: {0, 1},
+    OP_CONSTANT_LONG: {0, 1},
+    OP_NIL: {0, 1},
+    OP_TRUE: {0, 1},
+    OP_FALSE: {0, 1},
+    OP_EQUAL: {2, 1},
+    OP_NOT_EQUAL: {2, 1},
+    OP_GREATER: {2, 1},
+    OP_GREATER_EQUAL: {2, 1},
+    OP_LESS: {2, 1},
+    OP_LESS_EQUAL: {2, 1},
+    OP_ADD: {2, 1},
+    OP_SUBTRACT: {2, 1},
+    OP_MULTIPLY: {2, 1},
+    OP_DIVIDE: {2, 1},
+    OP_NOT: {1, 1},
+    OP_NEGATE: {1, 1},
+    OP_RETURN: {1, 0},
+}
+
+// verify checks that every instruction and operand is valid, that the stack
+// never underflows and that the code ends with OP_RETURN.
+func (chunk *Chunk) verify() error {
+    if len(chunk.lines) != len(chunk.code) {
+        return errors.New("loxc: line table does not match the code")
+    }
+
+    depth := 0
+    for offset := 0; offset < len(chunk.code); {
+        opCode := OpCode(chunk.code[offset])
+        effect, ok := stackEffects[opCode]
+        if !ok {
+            return fmt.Errorf("loxc: unknown instruction %d at offset %d", opCode, offset)
+        }
+
+        length := instructionLength(opCode)
+        if offset + length > len(chunk.code) {
+            return fmt.Errorf("loxc: truncated %v at offset %d", opCode, offset)
+        }
+        if opCode == OP_CONSTANT || opCode == OP_CONSTANT_LONG {
+            if index := constantIndex(chunk.code[offset:]); index >= len(chunk.constants) {
+                return fmt.Errorf("loxc: constant %d out of range at offset %d", index, offset)
+            }
+        }
+
+        if depth < effect[0] {
+            return fmt.Errorf("loxc: stack underflow at offset %d", offset)
+        }
+        depth += effect[1] - effect[0]
+
+        if opCode == OP_RETURN {
+            if offset + length != len(chunk.code) {
+                return fmt.Errorf("loxc: unreachable code after offset %d", offset)
+            }
+            return nil
+        }
+        offset += length
+    }
+
+    return errors.New("loxc: code does not end with OP_RETURN")
+}
+
+func instructionLength(opCode OpCode) int {
+    switch opCode {
+    case OP_CONSTANT:
+        return 2
+    case OP_CONSTANT_LONG:
+        return 4
+    }
+    return 1
+}
+
+// constantIndex decodes the operand of the constant instruction at the start
+// of code.
+func constantIndex(code []byte) int {
+    if OpCode(code[0]) == OP_CONSTANT_LONG {
+        return int(code[1]) << 16 | int(code[2]) << 8 | int(code[3])
+    }
+    return int(code[1])
+}
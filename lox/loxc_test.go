@@ -0,0 +1,161 @@
+package lox
+
+import (
+    "encoding/binary"
+    "errors"
+    "hash/crc32"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestLoxcRoundTrip(t *testing.T) {
+    sources := append(engineTests, "0" + strings.Repeat(" + 1", 300), "1 +\n2 *\n-\"x\"")
+
+    for _, source := range sources {
+        t.Run(source, func(t *testing.T) {
+            chunk, err := Compile(parseSource(t, source))
+            if err != nil {
+                t.Fatalf("compiling failed: %v\n", err)
+            }
+
+            data, err := chunk.MarshalBinary()
+            if err != nil {
+                t.Fatalf("encoding failed: %v\n", err)
+            }
+            if !IsLoxc(data) {
+                t.Errorf("encoded chunk is missing the magic number\n")
+            }
+
+            var decoded Chunk
+            if err := decoded.UnmarshalBinary(data); err != nil {
+                t.Fatalf("decoding failed: %v\n", err)
+            }
+            if !reflect.DeepEqual(*chunk, decoded) {
+                t.Errorf("Incorrect chunk.\nresult  :%+v\nexpected:%+v\n", decoded, *chunk)
+            }
+        })
+    }
+}
+
+func encodeForTest(t *testing.T, source string) []byte {
+    chunk, err := Compile(parseSource(t, source))
+    if err != nil {
+        t.Fatalf("compiling failed: %v\n", err)
+    }
+    data, err := chunk.MarshalBinary()
+    if err != nil {
+        t.Fatalf("encoding failed: %v\n", err)
+    }
+    return data
+}
+
+func resum(data []byte) {
+    binary.LittleEndian.PutUint32(data[6:], crc32.ChecksumIEEE(data[loxcHeaderSize:]))
+}
+
+func TestLoxcInvalid(t *testing.T) {
+    valid := encodeForTest(t, "(1 + 2) * -\"x\"")
+
+    tests := []struct {
+        name string
+        modify func([]byte) []byte
+        expected string
+    } {
+        {
+            name: "source text",
+            modify: func(data []byte) []byte { return []byte("1 + 2") },
+            expected: "loxc: not a compiled lox file",
+        },
+        {
+            name: "truncated header",
+            modify: func(data []byte) []byte { return data[:6] },
+            expected: "loxc: truncated header",
+        },
+        {
+            name: "newer version",
+            modify: func(data []byte) []byte {
+                binary.LittleEndian.PutUint16(data[4:], LoxcVersion + 1)
+                return data
+            },
+            expected: "loxc: unsupported version 2, this glox reads version 1",
+        },
+        {
+            name: "flipped bit",
+            modify: func(data []byte) []byte {
+                data[len(data) - 1] ^= 1
+                return data
+            },
+            expected: "loxc: checksum mismatch, file is corrupt",
+        },
+        {
+            name: "truncated body",
+            modify: func(data []byte) []byte {
+                data = data[:len(data) - 3]
+                resum(data)
+                return data
+            },
+            expected: "loxc: unexpected end of data",
+        },
+        {
+            name: "unknown instruction",
+            modify: func(data []byte) []byte {
+                data[loxcHeaderSize + 1] = 0xff
+                resum(data)
+                return data
+            },
+            expected: "loxc: unknown instruction 255 at offset 0",
+        },
+        {
+            name: "stack underflow",
+            modify: func(data []byte) []byte {
+                data[loxcHeaderSize + 1] = byte(OP_ADD)
+                resum(data)
+                return data
+            },
+            expected: "loxc: stack underflow at offset 0",
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            data := test.modify(append([]byte(nil), valid...))
+
+            var chunk Chunk
+            err := chunk.UnmarshalBinary(data)
+            if err == nil || err.Error() != test.expected {
+                t.Errorf("Incorrect error.\nresult  :%v\nexpected:%v\n", err, test.expected)
+            }
+        })
+    }
+}
+
+// TestLoxcCorruptionNeverPanics feeds every single-byte corruption and
+// truncation of a valid file, with a matching checksum, to the decoder and
+// runs whatever it accepts.
+func TestLoxcCorruptionNeverPanics(t *testing.T) {
+    valid := encodeForTest(t, "(1.5 + 2) * -\"x\" == !(3 >= 4) + \"long\"")
+    vm := NewVM()
+
+    check := func(data []byte) {
+        var chunk Chunk
+        if err := chunk.UnmarshalBinary(data); err == nil {
+            vm.Run(&chunk)
+        } else if errors.Is(err, ErrLoxcChecksum) {
+            t.Errorf("checksum was recomputed but still rejected\n")
+        }
+    }
+
+    for i := loxcHeaderSize; i < len(valid); i++ {
+        for _, value := range []byte{0, 1, 2, 0x7f, 0x80, 0xff, valid[i] ^ 1} {
+            data := append([]byte(nil), valid...)
+            data[i] = value
+            resum(data)
+            check(data)
+        }
+
+        data := append([]byte(nil), valid[:i]...)
+        resum(data)
+        check(data)
+    }
+}
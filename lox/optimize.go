@@ -0,0 +1,41 @@
+package lox
+
+// Optimize returns an equivalent expression with constant subexpressions
+// folded into literals and groupings removed. Evaluating the result gives
+// the same value, or the same runtime error, as evaluating expr: a constant
+// subexpression whose evaluation fails, such as -"x", is left in place so the
+// error still happens at runtime.
+//
+// Dead-code elimination will join constant folding here once the language
+// has statements to eliminate.
+func Optimize(expr Expr) Expr {
+    return Apply(expr, nil, func(cursor *Cursor) bool {
+        switch node := cursor.Node().(type) {
+        case Grouping:
+            cursor.Replace(node.expression)
+        case Unary:
+            if isLiteral(node.right) {
+                fold(cursor)
+            }
+        case Binary:
+            if isLiteral(node.left) && isLiteral(node.right) {
+                fold(cursor)
+            }
+        }
+        return true
+    })
+}
+
+func isLiteral(expr Expr) bool {
+    _, ok := expr.(Literal)
+    return ok
+}
+
+// fold replaces the expression under cursor with its value, unless
+// evaluating it is a runtime error.
+func fold(cursor *Cursor) {
+    value, err := Interpret(cursor.Node())
+    if err == nil {
+        cursor.Replace(Literal{ValueOf(value), cursor.Node().Line()})
+    }
+}
@@ -0,0 +1,69 @@
+package lox
+
+import "testing"
+
+func TestOptimizePreservesSemantics(t *testing.T) {
+    for _, source := range engineTests {
+        t.Run(source, func(t *testing.T) {
+            expression := parseSource(t, source)
+            expected, expectedErr := Interpret(expression)
+            result, err := Interpret(Optimize(expression))
+
+            assertSameResult(t, result, err, expected, expectedErr)
+        })
+    }
+}
+
+func TestOptimize(t *testing.T) {
+    tests := []struct {
+        name string
+        input string
+        expected string
+    } {
+        {
+            name: "constant arithmetic",
+            input: "2 * 3",
+            expected: "6",
+        },
+        {
+            name: "nested constant arithmetic",
+            input: "(1 + 2) * (10 - -4) / 7",
+            expected: "6",
+        },
+        {
+            name: "string concatenation",
+            input: "\"1\" + 1 + (1 + 1)",
+            expected: "112",
+        },
+        {
+            name: "negation of literals",
+            input: "!nil == !!true",
+            expected: "true",
+        },
+        {
+            name: "groupings are removed",
+            input: "((-\"x\"))",
+            expected: "(- x)",
+        },
+        {
+            name: "runtime error is not folded",
+            input: "(1 + 2) * -\"x\"",
+            expected: "(* 3 (- x))",
+        },
+        {
+            name: "operands of a failing expression are still folded",
+            input: "(1 - true) + (2 * 2)",
+            expected: "(+ (- 1 true) 4)",
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            result := Optimize(parseSource(t, test.input)).Print()
+
+            if result != test.expected {
+                t.Errorf("Incorrect result.\nresult  :%s\nexpected:%s\n", result, test.expected)
+            }
+        })
+    }
+}
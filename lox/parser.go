@@ -13,214 +13,452 @@ func (parserError ParserError) Error() string {
 	return parserError.token.String()
 }
 
-func Parse(tokens []Token) (Expr, error) {
-	current := 0
+// TokenSource supplies tokens to the parser one at a time. *Scanner
+// implements it, so a parser can consume input while it is being scanned.
+type TokenSource interface {
+	Next() (Token, error)
+}
 
-	peek := func() Token {
-		return tokens[current]
-	}
+type sliceSource struct {
+	tokens  []Token
+	current int
+}
 
-	isAtEnd := func() bool {
-		return peek().tokenType == EOF
+func (source *sliceSource) Next() (Token, error) {
+	if source.current >= len(source.tokens) {
+		return Token{tokenType: EOF, literal: StringVal("")}, nil
 	}
+	source.current++
+	return source.tokens[source.current-1], nil
+}
 
-	previous := func() Token {
-		return tokens[current-1]
-	}
+// DefaultMaxDepth is the nesting limit of parsers that do not set one.
+const DefaultMaxDepth = 10000
+
+// Parser is a Pratt parser for expressions, driven by the parselets of a
+// Grammar. Its zero value parses Lox and is ready to use, and reusing one
+// for many inputs avoids setting up a new parser each time.
+type Parser struct {
+	// Grammar is the table of rules to parse with, or that of Lox if it is
+	// nil.
+	Grammar *Grammar
+
+	// MaxDepth limits the height of the tree, that is how deeply
+	// subexpressions such as operands and groupings may nest, or is
+	// DefaultMaxDepth if it is zero. Every pass over the tree recurses once
+	// per level, so the limit turns input that would exhaust the stack
+	// into a parse error. Chains of left-associative operators count too,
+	// as each operator is the left operand of the next.
+	MaxDepth int
+
+	source    TokenSource
+	tokens    sliceSource
+	lookahead Token
+	last      Token
+	sourceErr error
+
+	// depth is the level in the tree of the expression being parsed, and
+	// deepest the deepest level any of it reaches.
+	depth   int
+	deepest int
+
+	// memo, when set, holds the results of parsing tokens; see
+	// parseMemoized.
+	memo   *memoTable
+	reused int
+}
 
-	advance := func() Token {
-		if !isAtEnd() {
-			current++
-		}
-		return previous()
-	}
+// memoEntry records what parsing at a precedence level gave, starting at
+// some token. The parse examined that token and the length tokens after it,
+// and consumed all of them but the last, so the result stays valid as long
+// as those tokens do. depth is how much deeper than its start the parse
+// nested, and offset and line are those of the starting token when expr was
+// built. steps are the expressions the infix loop built on the way to expr.
+// When a change to the tokens makes expr stale, the steps before the change
+// are kept, so that parsing again can pick up the loop from there.
+type memoEntry struct {
+	expr   Expr
+	length int
+	depth  int
+	offset int
+	line   int
+	steps  []memoStep
+}
 
-	check := func(tokenType TokenType) bool {
-		if isAtEnd() {
-			return false
-		}
-		return peek().tokenType == tokenType
-	}
+// memoStep is an expression built by the infix loop, from the first length
+// tokens of a memoEntry and examining the one after them. depth is how much
+// deeper than the loop it nested.
+type memoStep struct {
+	expr   Expr
+	length int
+	depth  int
+}
 
-	match := func(types ...TokenType) bool {
-		for i := 0; i < len(types); i++ {
-			if check(types[i]) {
-				advance()
-				return true
-			}
-		}
-		return false
-	}
+// memoTable has an entry per token and precedence level of a grammar, and
+// for each token a bound on the length of its entries, so that the entries
+// reaching some token can be found without looking at all of them.
+type memoTable struct {
+	entries []memoEntry
+	longest []int
+	levels  int
+}
 
-	reportError := func(token Token, message string) {
-		if token.tokenType == EOF {
-			log.Printf("[line %d] Error %s: %s\n", token.line, "at end", message)
-		} else {
-			log.Printf("[line %d] Error %s: %s\n", token.line, fmt.Sprintf("at '%s'", token.lexeme), message)
-		}
+func newMemoTable(tokens int, levels int) memoTable {
+	return memoTable{
+		entries: make([]memoEntry, tokens*levels),
+		longest: make([]int, tokens),
+		levels:  levels,
 	}
+}
 
-	consume := func(tokenType TokenType, message string) (Token, error) {
-		if check(tokenType) {
-			return advance(), nil
-		}
+func (memo *memoTable) entry(position int, level int) *memoEntry {
+	return &memo.entries[position*memo.levels+level]
+}
 
-		// TODO: refactor error reporting to its own package/function
-		reportError(peek(), message)
-		return peek(), ParserError{}
-	}
+// parseStart is where parsing an expression started, as returned by begin.
+type parseStart struct {
+	position int
+	depth    int
+	deepest  int
+}
 
-	// TODO: fix recursion
-	var unary func() (Expr, error)
-	var primary func() (Expr, error)
+func Parse(tokens []Token) (Expr, error) {
+	var parser Parser
+	return parser.Parse(tokens)
+}
 
-	unary = func() (Expr, error) {
-		if match(BANG, MINUS) {
-			operator := previous()
-			right, err := unary()
+// ParseFrom parses an expression from tokens as source produces them.
+func ParseFrom(source TokenSource) (Expr, error) {
+	var parser Parser
+	return parser.ParseFrom(source)
+}
 
-			if err != nil {
-				return nil, ParserError{}
-			}
+func (parser *Parser) Parse(tokens []Token) (Expr, error) {
+	parser.tokens = sliceSource{tokens: tokens}
+	return parser.ParseFrom(&parser.tokens)
+}
 
-			return Unary{operator, right}, nil
-		}
+// parseMemoized parses tokens like Parse, reusing the results recorded in
+// memo, which has an entry per token and grammar level, and recording new
+// ones. A reused result whose tokens have moved since it was recorded is
+// relocated.
+func (parser *Parser) parseMemoized(tokens []Token, memo *memoTable) (Expr, error) {
+	parser.memo = memo
+	return parser.Parse(tokens)
+}
 
-		expr, err := primary()
-		if err != nil {
-			return nil, ParserError{}
-		}
+func (parser *Parser) ParseFrom(source TokenSource) (Expr, error) {
+	*parser = Parser{
+		Grammar:  parser.Grammar,
+		MaxDepth: parser.MaxDepth,
+		source:   source,
+		tokens:   parser.tokens,
+		memo:     parser.memo,
+	}
+	parser.read()
 
-		return expr, nil
+	expr, err := parser.ParsePrecedence(PrecedenceNone)
+
+	// Drop the references to the input so a reused parser does not keep it
+	// alive.
+	sourceErr := parser.sourceErr
+	*parser = Parser{Grammar: parser.Grammar, MaxDepth: parser.MaxDepth, reused: parser.reused}
+
+	if sourceErr != nil {
+		return nil, sourceErr
+	}
+	if err != nil {
+		return nil, ParserError{}
 	}
 
-	factor := func() (Expr, error) {
-		expr, err := unary()
+	return expr, nil
+}
 
-		if err != nil {
-			return nil, ParserError{}
-		}
+// read fetches the next token. A failing source is treated as the end of
+// input, and its error is returned once parsing stops.
+func (parser *Parser) read() {
+	token, err := parser.source.Next()
+	if err != nil {
+		parser.sourceErr = err
+		token = Token{tokenType: EOF, literal: StringVal(""), line: parser.lookahead.line}
+	}
+	parser.lookahead = token
+}
 
-		for match(SLASH, STAR) {
-			operator := previous()
-			right, err := unary()
+func (parser *Parser) peek() Token {
+	return parser.lookahead
+}
 
-			if err != nil {
-				return nil, ParserError{}
-			}
+func (parser *Parser) isAtEnd() bool {
+	return parser.peek().tokenType == EOF
+}
 
-			expr = Binary{expr, operator, right}
-		}
+func (parser *Parser) previous() Token {
+	return parser.last
+}
 
-		return expr, nil
+func (parser *Parser) advance() Token {
+	if !parser.isAtEnd() {
+		parser.last = parser.lookahead
+		parser.read()
 	}
+	return parser.previous()
+}
 
-	term := func() (Expr, error) {
-		expr, err := factor()
+func (parser *Parser) check(tokenType TokenType) bool {
+	if parser.isAtEnd() {
+		return false
+	}
+	return parser.peek().tokenType == tokenType
+}
 
-		if err != nil {
-			return nil, ParserError{}
-		}
+func (parser *Parser) reportError(token Token, message string) {
+	if parser.sourceErr != nil {
+		return
+	}
+	if token.tokenType == EOF {
+		log.Printf("[line %d] Error %s: %s\n", token.line, "at end", message)
+	} else {
+		log.Printf("[line %d] Error %s: %s\n", token.line, fmt.Sprintf("at '%s'", token.lexeme), message)
+	}
+}
 
-		for match(MINUS, PLUS) {
-			operator := previous()
-			right, err := factor()
+// Peek returns the next token without consuming it.
+func (parser *Parser) Peek() Token {
+	return parser.peek()
+}
 
-			if err != nil {
-				return nil, ParserError{}
-			}
+// Advance consumes the next token and returns it. At the end of input it
+// consumes nothing, and returns the last token consumed.
+func (parser *Parser) Advance() Token {
+	return parser.advance()
+}
 
-			expr = Binary{expr, operator, right}
-		}
+// Check reports whether the next token has type tokenType, which is EOF at
+// the end of input.
+func (parser *Parser) Check(tokenType TokenType) bool {
+	return parser.peek().tokenType == tokenType
+}
 
-		return expr, nil
+// Expect consumes the next token if it has type tokenType, and reports
+// message as an error otherwise.
+func (parser *Parser) Expect(tokenType TokenType, message string) (Token, error) {
+	if parser.check(tokenType) {
+		return parser.advance(), nil
 	}
 
-	comparison := func() (Expr, error) {
-		expr, err := term()
+	// TODO: refactor error reporting to its own package/function
+	parser.reportError(parser.peek(), message)
+	return parser.peek(), ParserError{}
+}
 
-		if err != nil {
-			return nil, ParserError{}
-		}
+func (parser *Parser) grammar() *Grammar {
+	if parser.Grammar != nil {
+		return parser.Grammar
+	}
+	return loxGrammar
+}
 
-		for match(GREATER, GREATER_EQUAL, LESS, LESS_EQUAL) {
-			operator := previous()
-			right, err := term()
+// ParsePrecedence parses an expression whose infix operators, outside of
+// groupings and the like, have a precedence higher than precedence. Infix
+// parselets use it to parse their right operand.
+func (parser *Parser) ParsePrecedence(precedence Precedence) (Expr, error) {
+	grammar := parser.grammar()
+	level := grammar.level(precedence)
 
-			if err != nil {
-				return nil, ParserError{}
-			}
+	if expr, ok := parser.reuse(level); ok {
+		return expr, nil
+	}
+	start := parser.begin()
 
-			expr = Binary{expr, operator, right}
+	if err := parser.nest(parser.peek()); err != nil {
+		return nil, parser.abandon(start)
+	}
+
+	expr, steps := parser.resume(level)
+	if expr == nil {
+		token := parser.peek()
+		prefix := grammar.rules[token.tokenType].Prefix
+		if prefix == nil || parser.isAtEnd() {
+			parser.reportError(token, "Expect expression")
+			return nil, parser.abandon(start)
+		}
+		parser.advance()
+
+		var err error
+		expr, err = prefix(parser, token)
+		if err != nil {
+			return nil, parser.abandon(start)
 		}
-		return expr, nil
 	}
 
-	equality := func() (Expr, error) {
-		expr, err := comparison()
+	for {
+		token := parser.peek()
+		rule := grammar.rules[token.tokenType]
+		if rule.Infix == nil || rule.Precedence <= precedence || parser.isAtEnd() {
+			break
+		}
+		parser.advance()
 
+		if err := parser.sink(token); err != nil {
+			return nil, parser.abandon(start)
+		}
+		var err error
+		expr, err = rule.Infix(parser, expr, token)
 		if err != nil {
-			return nil, ParserError{}
+			return nil, parser.abandon(start)
 		}
+		steps = parser.step(steps, start, expr)
+	}
 
-		for match(BANG_EQUAL, EQUAL_EQUAL) {
-			operator := previous()
-			right, err := comparison()
+	parser.unnest()
+	return parser.remember(level, start, expr, steps), nil
+}
 
-			if err != nil {
-				return nil, ParserError{}
-			}
+// position returns the index of the lookahead token in a memoized parse.
+func (parser *Parser) position() int {
+	return parser.tokens.current - 1
+}
 
-			expr = Binary{expr, operator, right}
-		}
+// reuse looks up a still valid result of parsing at level from the
+// lookahead token and, if there is one, skips the tokens it consumed.
+func (parser *Parser) reuse(level int) (Expr, bool) {
+	if parser.memo == nil {
+		return nil, false
+	}
 
-		return expr, nil
+	start := parser.position()
+	entry := parser.memo.entry(start, level)
+	if entry.expr == nil || parser.depth+entry.depth > parser.maxDepth() {
+		return nil, false
 	}
 
-	expression := func() (Expr, error) {
-		return equality()
+	token := parser.tokens.tokens[start]
+	if token.offset != entry.offset || token.line != entry.line {
+		entry.expr = relocate(entry.expr, token.offset-entry.offset, token.line-entry.line)
+		entry.offset = token.offset
+		entry.line = token.line
+		// The steps would need relocating too, which is not worth it for
+		// the chance that the result goes stale later.
+		entry.steps = nil
 	}
 
-	primary = func() (Expr, error) {
-		if match(FALSE) {
-			return Literal{false}, nil
-		}
-		if match(TRUE) {
-			return Literal{true}, nil
-		}
-		if match(NIL) {
-			return Literal{nil}, nil
-		}
+	parser.skip(start+entry.length, entry.depth)
+	return entry.expr, true
+}
 
-		if match(NUMBER) {
-			return Literal{previous().literal}, nil
-		}
+// resume looks up the steps left of a stale result of parsing at level from
+// the lookahead token and, if there are any, skips to the end of the last
+// one. It returns the expression that step built and the steps up to it.
+func (parser *Parser) resume(level int) (Expr, []memoStep) {
+	if parser.memo == nil {
+		return nil, nil
+	}
 
-		if match(STRING) {
-			return Literal{previous().literal}, nil
-		}
+	// Stale results start before the change that made them stale, so they
+	// have only moved if the tokens have changed again since, and are not
+	// worth relocating then.
+	start := parser.position()
+	entry := parser.memo.entry(start, level)
+	token := parser.tokens.tokens[start]
+	if token.offset != entry.offset || token.line != entry.line {
+		return nil, nil
+	}
 
-		if match(LEFT_PAREN) {
-			expr, err := expression()
+	steps := entry.steps
+	for len(steps) > 0 && parser.depth+steps[len(steps)-1].depth > parser.maxDepth() {
+		steps = steps[:len(steps)-1]
+	}
+	if len(steps) == 0 {
+		return nil, nil
+	}
 
-			if err != nil {
-				return nil, ParserError{}
-			}
+	last := steps[len(steps)-1]
+	parser.skip(start+last.length, last.depth)
+	return last.expr, steps
+}
 
-			consume(RIGHT_PAREN, "Expect ')' after expression")
-			return Grouping{expr}, nil
-		}
+// skip moves on to the token at end, as when parsing the tokens up to it
+// nested depth levels deeper than the current level.
+func (parser *Parser) skip(end int, depth int) {
+	parser.last = parser.tokens.tokens[end-1]
+	parser.tokens.current = end
+	parser.read()
+	parser.reused++
+	parser.deepest = max(parser.deepest, parser.depth+depth)
+}
 
-		// return token with error
-		reportError(peek(), "Expect expression")
-		return nil, ParserError{}
+// step records expr as built by the infix loop of parsing since start.
+func (parser *Parser) step(steps []memoStep, start parseStart, expr Expr) []memoStep {
+	if parser.memo == nil {
+		return nil
 	}
+	return append(steps, memoStep{expr, parser.position() - start.position, parser.deepest - parser.depth})
+}
 
-	expr, err := expression()
+// begin is called when parsing an expression starts, and remember when it
+// is done or abandon when it fails.
+func (parser *Parser) begin() parseStart {
+	start := parseStart{parser.position(), parser.depth, parser.deepest}
+	parser.deepest = parser.depth
+	return start
+}
 
-	if err != nil {
-		return nil, ParserError{}
+// remember records expr as the result of parsing at level since start, and
+// the steps of the infix loop that led to it.
+func (parser *Parser) remember(level int, start parseStart, expr Expr, steps []memoStep) Expr {
+	if parser.memo != nil {
+		token := parser.tokens.tokens[start.position]
+		length := parser.position() - start.position
+		*parser.memo.entry(start.position, level) = memoEntry{
+			expr, length, parser.deepest - parser.depth, token.offset, token.line, steps,
+		}
+		parser.memo.longest[start.position] = max(parser.memo.longest[start.position], length)
 	}
+	parser.deepest = max(parser.deepest, start.deepest)
+	return expr
+}
 
-	return expr, nil
+// abandon restores the nesting from before start when parsing an expression
+// fails, so that a parselet recovering from the error goes on at the right
+// depth.
+func (parser *Parser) abandon(start parseStart) error {
+	parser.depth = start.depth
+	parser.deepest = start.deepest
+	return ParserError{}
+}
+
+func (parser *Parser) maxDepth() int {
+	if parser.MaxDepth > 0 {
+		return parser.MaxDepth
+	}
+	return DefaultMaxDepth
+}
+
+// nest enters a subexpression, reporting an error at token if that nests
+// too deeply. unnest leaves it again.
+func (parser *Parser) nest(token Token) error {
+	parser.depth++
+	parser.deepest = max(parser.deepest, parser.depth)
+	if parser.depth > parser.maxDepth() {
+		parser.reportError(token, "Expression nested too deeply")
+		return ParserError{}
+	}
+	return nil
+}
+
+func (parser *Parser) unnest() {
+	parser.depth--
+}
+
+// sink moves the expression parsed so far a level deeper, as it becomes the
+// left operand of the infix operator token, reporting an error at token if
+// that nests it too deeply.
+func (parser *Parser) sink(token Token) error {
+	parser.deepest++
+	if parser.deepest > parser.maxDepth() {
+		parser.reportError(token, "Expression nested too deeply")
+		return ParserError{}
+	}
+	return nil
 }
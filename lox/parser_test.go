@@ -1,6 +1,12 @@
 package lox
 
-import "testing"
+import (
+	"io"
+	"log"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
 
 func TestParseValid(t *testing.T) {
 	tests := []struct {
@@ -11,133 +17,150 @@ func TestParseValid(t *testing.T) {
 		{
 			name: "one plus one",
 			input: []Token{
-				{tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-				{tokenType: PLUS, lexeme: "+", literal: "", line: 1},
-				{tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-				{tokenType: EOF, lexeme: "", literal: "", line: 1},
+				{tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+				{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+				{tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+				{tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
 			},
 			expected: Binary{
-				left:     Literal{value: 1.0},
-				operator: Token{tokenType: PLUS, lexeme: "+", literal: "", line: 1},
-				right:     Literal{value: 1.0},
+				left:     Literal{value: NumberVal(1.0), line: 1},
+				operator: Token{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+				right:     Literal{value: NumberVal(1.0), line: 1},
+				line: 1,
 			},
 		},
 		{
             name: "left-associative operations: one plus one minus two",
 			input: []Token{
-				{tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-				{tokenType: PLUS, lexeme: "+", literal: "", line: 1},
-				{tokenType: NUMBER, lexeme: "1.1234", literal: 1.1234, line: 1},
-				{tokenType: MINUS, lexeme: "-", literal: "", line: 1},
-				{tokenType: NUMBER, lexeme: "2", literal: 2.0, line: 1},
-				{tokenType: EOF, lexeme: "", literal: "", line: 1},
+				{tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+				{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+				{tokenType: NUMBER, lexeme: "1.1234", literal: NumberVal(1.1234), line: 1},
+				{tokenType: MINUS, lexeme: "-", literal: StringVal(""), line: 1},
+				{tokenType: NUMBER, lexeme: "2", literal: NumberVal(2.0), line: 1},
+				{tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
 			},
 			expected: Binary{
 				left:     Binary{
-                    left: Literal{1.0},
-                    operator: Token{tokenType: PLUS, lexeme: "+", literal: "", line: 1},
-                    right: Literal{1.1234},
+                    left: Literal{value: NumberVal(1.0), line: 1},
+                    operator: Token{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+                    right: Literal{value: NumberVal(1.1234), line: 1},
+                    line: 1,
                 },
-				operator: Token{tokenType: MINUS, lexeme: "-", literal: "", line: 1},
-				right:     Literal{value: 2.0},
+				operator: Token{tokenType: MINUS, lexeme: "-", literal: StringVal(""), line: 1},
+				right:     Literal{value: NumberVal(2.0), line: 1},
+				line: 1,
 			},
 		},
 		{
             name: "complex arithmatic: (1.1 + 2 - 10) * 1.10000001 / 2.24354352",
 			input: []Token{
-				{tokenType: LEFT_PAREN, lexeme: "(", literal: "", line: 1},
-				{tokenType: NUMBER, lexeme: "1.1", literal: 1.1, line: 1},
-				{tokenType: PLUS, lexeme: "+", literal: "", line: 1},
-				{tokenType: NUMBER, lexeme: "2", literal: 2, line: 1},
-				{tokenType: MINUS, lexeme: "-", literal: "", line: 1},
-				{tokenType: NUMBER, lexeme: "10", literal: 10, line: 1},
-				{tokenType: RIGHT_PAREN, lexeme: ")", literal: "", line: 1},
-				{tokenType: STAR, lexeme: "*", literal: "", line: 1},
-				{tokenType: NUMBER, lexeme: "1.10000001", literal: 1.10000001, line: 1},
-				{tokenType: SLASH, lexeme: "/", literal: "", line: 1},
-				{tokenType: NUMBER, lexeme: "2.24354352", literal: 2.24354352, line: 1},
-				{tokenType: EOF, lexeme: "", literal: "", line: 1},
+				{tokenType: LEFT_PAREN, lexeme: "(", literal: StringVal(""), line: 1},
+				{tokenType: NUMBER, lexeme: "1.1", literal: NumberVal(1.1), line: 1},
+				{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+				{tokenType: NUMBER, lexeme: "2", literal: NumberVal(2), line: 1},
+				{tokenType: MINUS, lexeme: "-", literal: StringVal(""), line: 1},
+				{tokenType: NUMBER, lexeme: "10", literal: NumberVal(10), line: 1},
+				{tokenType: RIGHT_PAREN, lexeme: ")", literal: StringVal(""), line: 1},
+				{tokenType: STAR, lexeme: "*", literal: StringVal(""), line: 1},
+				{tokenType: NUMBER, lexeme: "1.10000001", literal: NumberVal(1.10000001), line: 1},
+				{tokenType: SLASH, lexeme: "/", literal: StringVal(""), line: 1},
+				{tokenType: NUMBER, lexeme: "2.24354352", literal: NumberVal(2.24354352), line: 1},
+				{tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
 			},
 			expected: Binary{
                 left: Binary{
                     left: Grouping{
                         expression: Binary{
                             left: Binary{
-                                left: Literal{1.1},
-                                operator: Token{PLUS, "+", "", 1},
-                                right: Literal{2},
+                                left: Literal{value: NumberVal(1.1), line: 1},
+                                operator: Token{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+                                right: Literal{value: NumberVal(2), line: 1},
+                                line: 1,
                             },
-                            operator: Token{MINUS, "-", "", 1},
-                            right: Literal{10},
+                            operator: Token{tokenType: MINUS, lexeme: "-", literal: StringVal(""), line: 1},
+                            right: Literal{value: NumberVal(10), line: 1},
+                            line: 1,
                         },
+                        line: 1,
                     },
-                    operator: Token{STAR, "*", "", 1},
-                    right: Literal{1.10000001},
+                    operator: Token{tokenType: STAR, lexeme: "*", literal: StringVal(""), line: 1},
+                    right: Literal{value: NumberVal(1.10000001), line: 1},
+                    line: 1,
                 },
-                operator: Token{SLASH, "/", "", 1},
-                right: Literal{2.24354352} ,
+                operator: Token{tokenType: SLASH, lexeme: "/", literal: StringVal(""), line: 1},
+                right: Literal{value: NumberVal(2.24354352), line: 1} ,
+                line: 1,
             },
 		},
 		{
 			name: "negated decimal multiply grouped expression: -123 * (1 + 1)",
 			input: []Token{
-				{tokenType: MINUS, lexeme: "-", literal: "", line: 1},
-				{tokenType: NUMBER, lexeme: "123", literal: 123.0, line: 1},
-				{tokenType: STAR, lexeme: "*", literal: "", line: 1},
-				{tokenType: LEFT_PAREN, lexeme: "(", literal: "", line: 1},
-				{tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-				{tokenType: PLUS, lexeme: "+", literal: "", line: 1},
-				{tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-				{tokenType: RIGHT_PAREN, lexeme: ")", literal: "", line: 1},
-				{tokenType: EOF, lexeme: "", literal: "", line: 1},
+				{tokenType: MINUS, lexeme: "-", literal: StringVal(""), line: 1},
+				{tokenType: NUMBER, lexeme: "123", literal: NumberVal(123.0), line: 1},
+				{tokenType: STAR, lexeme: "*", literal: StringVal(""), line: 1},
+				{tokenType: LEFT_PAREN, lexeme: "(", literal: StringVal(""), line: 1},
+				{tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+				{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+				{tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+				{tokenType: RIGHT_PAREN, lexeme: ")", literal: StringVal(""), line: 1},
+				{tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
 			},
 			expected: Binary{
 				left: Unary{
-					operator: Token{MINUS, "-", "", 1},
-					right:    Literal{123.0},
+					operator: Token{tokenType: MINUS, lexeme: "-", literal: StringVal(""), line: 1},
+					right:    Literal{value: NumberVal(123.0), line: 1},
+					line: 1,
 				},
-				operator: Token{STAR, "*", "", 1},
+				operator: Token{tokenType: STAR, lexeme: "*", literal: StringVal(""), line: 1},
 				right: Grouping{
 					expression: Binary{
-						left:     Literal{value: 1.0},
-						operator: Token{tokenType: PLUS, lexeme: "+", literal: "", line: 1},
-						right:    Literal{value: 1.0},
+						left:     Literal{value: NumberVal(1.0), line: 1},
+						operator: Token{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+						right:    Literal{value: NumberVal(1.0), line: 1},
+						line: 1,
 					},
+					line: 1,
 				},
+				line: 1,
 			},
 		},
 		{
 			name: "comparision between two groupings: (1 + 1) == (1 + 1)",
 			input: []Token{
-				{tokenType: LEFT_PAREN, lexeme: "(", literal: "", line: 1},
-				{tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-				{tokenType: PLUS, lexeme: "+", literal: "", line: 1},
-				{tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-				{tokenType: RIGHT_PAREN, lexeme: ")", literal: "", line: 1},
-				{tokenType: EQUAL_EQUAL, lexeme: "==", literal: "", line: 1},
-				{tokenType: LEFT_PAREN, lexeme: "(", literal: "", line: 1},
-				{tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-				{tokenType: PLUS, lexeme: "+", literal: "", line: 1},
-				{tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-				{tokenType: RIGHT_PAREN, lexeme: ")", literal: "", line: 1},
-				{tokenType: EOF, lexeme: "", literal: "", line: 1},
+				{tokenType: LEFT_PAREN, lexeme: "(", literal: StringVal(""), line: 1},
+				{tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+				{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+				{tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+				{tokenType: RIGHT_PAREN, lexeme: ")", literal: StringVal(""), line: 1},
+				{tokenType: EQUAL_EQUAL, lexeme: "==", literal: StringVal(""), line: 1},
+				{tokenType: LEFT_PAREN, lexeme: "(", literal: StringVal(""), line: 1},
+				{tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+				{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+				{tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+				{tokenType: RIGHT_PAREN, lexeme: ")", literal: StringVal(""), line: 1},
+				{tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
 			},
 			expected: Binary{
 				left: Grouping{
 					expression: Binary{
-						left:     Literal{value: 1.0},
-						operator: Token{tokenType: PLUS, lexeme: "+", literal: "", line: 1},
-						right:    Literal{value: 1.0},
+						left:     Literal{value: NumberVal(1.0), line: 1},
+						operator: Token{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+						right:    Literal{value: NumberVal(1.0), line: 1},
+						line: 1,
 					},
+					line: 1,
 				},
-				operator: Token{tokenType: EQUAL_EQUAL, lexeme: "==", literal: "", line: 1},
+				operator: Token{tokenType: EQUAL_EQUAL, lexeme: "==", literal: StringVal(""), line: 1},
 				right: Grouping{
 					expression: Binary{
-						left:     Literal{value: 1.0},
-						operator: Token{tokenType: PLUS, lexeme: "+", literal: "", line: 1},
-						right:    Literal{value: 1.0},
+						left:     Literal{value: NumberVal(1.0), line: 1},
+						operator: Token{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+						right:    Literal{value: NumberVal(1.0), line: 1},
+						line: 1,
 					},
+					line: 1,
 				},
+				line: 1,
 			},
 		},
 	}
@@ -166,46 +189,46 @@ func TestParseInvalid(t *testing.T) {
         {
             name: "invalid expression: 1 + + 1",
             input: []Token{
-                {tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-                {tokenType: PLUS, lexeme: "+", literal: "", line: 1},
-                {tokenType: PLUS, lexeme: "+", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-                {tokenType: EOF, lexeme: "", literal: "", line: 1},
+                {tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+                {tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+                {tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+                {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
             },
             expected: nil,
         },
         // {
         //     name: "invalid grouping with extra right paren: (1 + 1))",
         //     input: []Token{
-        //         {tokenType: LEFT_PAREN, lexeme: "(", literal: "", line: 1},
-        //         {tokenType: NUMBER, lexeme: "1", literal: "1", line: 1},
-        //         {tokenType: PLUS, lexeme: "+", literal: "", line: 1},
-        //         {tokenType: NUMBER, lexeme: "1", literal: "1", line: 1},
-        //         {tokenType: RIGHT_PAREN, lexeme: ")", literal: "", line: 1},
-        //         {tokenType: RIGHT_PAREN, lexeme: ")", literal: "", line: 1},
-        //         {tokenType: EOF, lexeme: "", literal: "", line: 1},
+        //         {tokenType: LEFT_PAREN, lexeme: "(", literal: StringVal(""), line: 1},
+        //         {tokenType: NUMBER, lexeme: "1", literal: StringVal("1"), line: 1},
+        //         {tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+        //         {tokenType: NUMBER, lexeme: "1", literal: StringVal("1"), line: 1},
+        //         {tokenType: RIGHT_PAREN, lexeme: ")", literal: StringVal(""), line: 1},
+        //         {tokenType: RIGHT_PAREN, lexeme: ")", literal: StringVal(""), line: 1},
+        //         {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
         //     },
         //     expected: nil,
         // },
         // {
         //     name: "invalid grouping with no left paren: 1 + 1)",
         //     input: []Token{
-        //         {tokenType: NUMBER, lexeme: "1", literal: "1", line: 1},
-        //         {tokenType: PLUS, lexeme: "+", literal: "", line: 1},
-        //         {tokenType: NUMBER, lexeme: "1", literal: "1", line: 1},
-        //         {tokenType: RIGHT_PAREN, lexeme: ")", literal: "", line: 1},
-        //         {tokenType: EOF, lexeme: "", literal: "", line: 1},
+        //         {tokenType: NUMBER, lexeme: "1", literal: StringVal("1"), line: 1},
+        //         {tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+        //         {tokenType: NUMBER, lexeme: "1", literal: StringVal("1"), line: 1},
+        //         {tokenType: RIGHT_PAREN, lexeme: ")", literal: StringVal(""), line: 1},
+        //         {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
         //     },
         //     expected: nil,
         // },
         // {
         //     name: "invalid grouping with no right paren: 1 + 1)",
         //     input: []Token{
-        //         {tokenType: LEFT_PAREN, lexeme: "(", literal: "", line: 1},
-        //         {tokenType: NUMBER, lexeme: "1", literal: "1", line: 1},
-        //         {tokenType: PLUS, lexeme: "+", literal: "", line: 1},
-        //         {tokenType: NUMBER, lexeme: "1", literal: "1", line: 1},
-        //         {tokenType: EOF, lexeme: "", literal: "", line: 1},
+        //         {tokenType: LEFT_PAREN, lexeme: "(", literal: StringVal(""), line: 1},
+        //         {tokenType: NUMBER, lexeme: "1", literal: StringVal("1"), line: 1},
+        //         {tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+        //         {tokenType: NUMBER, lexeme: "1", literal: StringVal("1"), line: 1},
+        //         {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
         //     },
         //     expected: nil,
         // },
@@ -224,3 +247,141 @@ func TestParseInvalid(t *testing.T) {
 
 
 }
+
+func BenchmarkParse(b *testing.B) {
+	for _, input := range benchmarkInputs {
+		b.Run(input.name, func(b *testing.B) {
+			tokens, err := Scan(input.source)
+			if err != nil {
+				b.Fatal(err)
+			}
+			var parser Parser
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				parser.Parse(tokens)
+			}
+		})
+	}
+}
+
+func TestParseFromScanner(t *testing.T) {
+	expression, err := ParseFrom(NewScanner(iotest.HalfReader(strings.NewReader("-123 *\n(1 + 1)"))))
+	if err != nil {
+		t.Fatalf("parsing failed: %v\n", err)
+	}
+
+	expected := "(* (- 123) (group (+ 1 1)))"
+	if expression.Print() != expected {
+		t.Errorf("result was incorrect.\nresult  :%s\nexpected:%s\n", expression.Print(), expected)
+	}
+}
+
+func TestParserReuse(t *testing.T) {
+	var parser Parser
+	for _, input := range []string{"1 + 2 * 3", "(1 + 2) * 3", "!true == false"} {
+		tokens, err := Scan(input)
+		if err != nil {
+			t.Fatalf("scanning failed: %v\n", err)
+		}
+
+		reused, err := parser.Parse(tokens)
+		if err != nil {
+			t.Fatalf("parsing failed: %v\n", err)
+		}
+		fresh, _ := Parse(tokens)
+		if reused != fresh {
+			t.Errorf("result was incorrect.\nresult  :%+v\nexpected:%+v\n", reused, fresh)
+		}
+	}
+}
+
+func TestParseMaxDepth(t *testing.T) {
+	output := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(output)
+
+	tests := []struct {
+		name     string
+		source   string
+		maxDepth int
+		valid    bool
+	}{
+		{
+			name:   "100,000 nested parentheses",
+			source: strings.Repeat("(", 100000) + "1" + strings.Repeat(")", 100000),
+		},
+		{
+			name:   "100,000 unary minuses",
+			source: strings.Repeat("-", 100000) + "1",
+		},
+		{
+			name:   "unclosed parentheses",
+			source: strings.Repeat("(", 100000),
+		},
+		{
+			name:   "at the default limit",
+			source: "-" + strings.Repeat("-(", DefaultMaxDepth/2-1) + "1" + strings.Repeat(")", DefaultMaxDepth/2-1),
+			valid:  true,
+		},
+		{
+			name:   "past the default limit",
+			source: "--" + strings.Repeat("-(", DefaultMaxDepth/2-1) + "1" + strings.Repeat(")", DefaultMaxDepth/2-1),
+		},
+		{
+			name:   "100,000 additions",
+			source: strings.Repeat("1 + ", 100000) + "1",
+		},
+		{
+			name:   "additions at the default limit",
+			source: strings.Repeat("1 + ", DefaultMaxDepth-1) + "1",
+			valid:  true,
+		},
+		{
+			name:   "additions past the default limit",
+			source: strings.Repeat("1 + ", DefaultMaxDepth) + "1",
+		},
+		{
+			name:     "at a custom limit",
+			source:   "!!(1 == 2) + ((((3))))",
+			maxDepth: 6,
+			valid:    true,
+		},
+		{
+			name:     "past a custom limit",
+			source:   "!!(1 == 2) + (((((3)))))",
+			maxDepth: 6,
+		},
+		{
+			name:     "operator chain past a custom limit",
+			source:   "!!(1 == 2 == 3) + 4",
+			maxDepth: 6,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokens, err := Scan(test.source)
+			if err != nil {
+				t.Fatalf("scanning failed: %v\n", err)
+			}
+
+			parser := Parser{MaxDepth: test.maxDepth}
+			expression, err := parser.Parse(tokens)
+			if (err == nil) != test.valid {
+				t.Fatalf("result was incorrect.\nerror   :%v\nexpected valid: %v\n", err, test.valid)
+			}
+			if expression == nil {
+				return
+			}
+
+			interpretBothPaths(t, expression)
+			chunk, err := Compile(expression)
+			if err != nil {
+				t.Fatalf("compiling failed: %v\n", err)
+			}
+			NewVM().Run(chunk)
+		})
+	}
+}
@@ -1,266 +1,311 @@
 package lox
 
 import (
-	"log"
-	"strconv"
+    "fmt"
+    "io"
+    "strconv"
 )
 
-func Scan(text string) ([]Token, error) {
-    runes := []rune(text)
+const scannerBufferSize = 4096
+
+type ScanError struct {
+    line int
+    message string
+}
 
-    start := 0
-    current := 0
-    line := 1
+func (scanError ScanError) Error() string {
+    return fmt.Sprintf("[line %d] Error: %s", scanError.line, scanError.message)
+}
 
-    tokens := make([]Token, 0)
+// Scanner reads tokens lazily from an io.Reader or a string. It only keeps
+// the token being scanned plus one read's worth of lookahead, so arbitrarily
+// large inputs can be scanned in bounded memory.
+//
+// Lexemes and string literals are substrings of the scanned text rather
+// than copies of it, and literals are stored as Values, so scanning a token
+// does not allocate.
+type Scanner struct {
+    reader io.Reader
+    readErr error
+    chunk []byte
 
-    advance := func() rune {
-        current++
-        return runes[current - 1]
-    }
+    // source holds the input from the start of the current token on, and
+    // base is the offset of source[0] in the whole input. start and current
+    // are offsets into source.
+    source string
+    base int
+    start int
+    current int
+    line int
+}
 
-    addToken := func(tokenType TokenType) {
-        tokens = append(tokens, Token{ tokenType, string(runes[start:current]), "", line })
+func NewScanner(reader io.Reader) *Scanner {
+    return &Scanner{
+        reader: reader,
+        chunk: make([]byte, scannerBufferSize),
+        line: 1,
     }
+}
+
+// NewStringScanner scans source in place, without reading or copying it.
+func NewStringScanner(source string) *Scanner {
+    scanner := stringScanner(source)
+    return &scanner
+}
 
-    isAtEnd := func() bool {
-        return current >= len(runes)
+func stringScanner(source string) Scanner {
+    return Scanner{
+        readErr: io.EOF,
+        source: source,
+        line: 1,
     }
+}
 
-    match := func(expected rune) bool {
-        if isAtEnd() || runes[current] != expected {
-            return false
+func Scan(text string) ([]Token, error) {
+    scanner := stringScanner(text)
+    var tokens []Token
+
+    for {
+        token, err := scanner.Next()
+        if err != nil {
+            return nil, err
         }
 
-        current++
-        
-        return true
+        tokens = append(tokens, token)
+        if token.tokenType == EOF {
+            return tokens, nil
+        }
     }
+}
 
-    peek := func() rune {
-        if isAtEnd() {
-            return '\x00'
+// fill makes sure at least n bytes from current on are available, reading
+// more input if necessary. It reports whether they are.
+func (scanner *Scanner) fill(n int) bool {
+    for scanner.current + n > len(scanner.source) {
+        if scanner.readErr != nil {
+            return false
         }
-        return runes[current]
-    }
 
-    tokenizeString := func() {
-        for peek() != '"' && !isAtEnd() {
-            if peek() == '\n' {
-                line++
-            }
-            advance()
+        // Reading at least as much as is kept doubles the buffered text
+        // on every read, so a token longer than the buffer is copied an
+        // amortized constant number of times.
+        kept := len(scanner.source) - scanner.start
+        if kept > len(scanner.chunk) {
+            scanner.chunk = make([]byte, kept)
         }
 
-        if isAtEnd() {
-           return
+        read, err := scanner.reader.Read(scanner.chunk)
+        if err != nil {
+            scanner.readErr = err
+        }
+        if read > 0 {
+            scanner.source = scanner.source[scanner.start:] + string(scanner.chunk[:read])
+            scanner.base += scanner.start
+            scanner.current -= scanner.start
+            scanner.start = 0
         }
+    }
+    return true
+}
+
+func (scanner *Scanner) isAtEnd() bool {
+    return !scanner.fill(1)
+}
 
-        advance()
+func (scanner *Scanner) advance() byte {
+    scanner.current++
+    return scanner.source[scanner.current - 1]
+}
 
-        lexeme := string(runes[start: current])
-        value := string(runes[start + 1: current - 1])
-        tokens = append(tokens, Token{ STRING, lexeme, value, line })
+func (scanner *Scanner) match(expected byte) bool {
+    if scanner.isAtEnd() || scanner.source[scanner.current] != expected {
+        return false
     }
 
-    isDigit := func(c rune) bool {
-        return c >= '0' && c <= '9'
+    scanner.current++
+
+    return true
+}
+
+func (scanner *Scanner) peek() byte {
+    if scanner.isAtEnd() {
+        return '\x00'
     }
+    return scanner.source[scanner.current]
+}
 
-    peekNext := func() rune {
-        if current + 1 >= len(runes) {
-            return '\x00'
-        }
-        return runes[current + 1]
+func (scanner *Scanner) peekNext() byte {
+    if !scanner.fill(2) {
+        return '\x00'
     }
+    return scanner.source[scanner.current + 1]
+}
 
-    number := func() {
-        for isDigit(peek()) {
-            advance()
-        }
+func (scanner *Scanner) lexeme() string {
+    return scanner.source[scanner.start:scanner.current]
+}
+
+func (scanner *Scanner) makeToken(tokenType TokenType) Token {
+    return scanner.literalToken(tokenType, StringVal(""))
+}
+
+func (scanner *Scanner) literalToken(tokenType TokenType, literal Value) Token {
+    return Token{ tokenType, scanner.lexeme(), literal, scanner.line, scanner.base + scanner.start }
+}
 
-        if peek() == '.' && isDigit(peekNext()) {
-            advance()
-            for isDigit(peek()) {
-                advance()
+func isDigit(c byte) bool {
+    return c >= '0' && c <= '9'
+}
+
+func isAlpha(c byte) bool {
+    return (c >= 'a' && c <= 'z') ||
+           (c >= 'A' && c <= 'Z') ||
+           (c == '_');
+}
+
+func isAlphaNumeric(c byte) bool {
+    return isAlpha(c) || isDigit(c)
+}
+
+// Next returns the next token of the input. Once the input is exhausted it
+// returns an EOF token on every call.
+func (scanner *Scanner) Next() (Token, error) {
+    for {
+        scanner.start = scanner.current
+        if scanner.isAtEnd() {
+            if scanner.readErr != io.EOF {
+                return Token{}, scanner.readErr
             }
+            return scanner.makeToken(EOF), nil
         }
 
-        numberString := string(runes[start: current])
-        number, err := strconv.ParseFloat(numberString, 64)
-        if err != nil {
-            log.Fatalln("Error scanning number")
+        token, found, err := scanner.scanToken()
+        if err != nil || found {
+            return token, err
         }
-        tokens = append(tokens, Token{ NUMBER, numberString, number, line })
     }
+}
 
-    isAlpha := func(c rune) bool {
-        return (c >= 'a' && c <= 'z') ||
-               (c >= 'A' && c <= 'Z') ||
-               (c == '_');
+// scanToken scans one lexeme. found is false for whitespace, comments and
+// unterminated strings, which produce no token.
+func (scanner *Scanner) scanToken() (token Token, found bool, err error) {
+    c := scanner.advance()
+
+    switch c {
+    case '(':
+        return scanner.makeToken(LEFT_PAREN), true, nil
+    case ')':
+        return scanner.makeToken(RIGHT_PAREN), true, nil
+    case '{':
+        return scanner.makeToken(LEFT_BRACE), true, nil
+    case '}':
+        return scanner.makeToken(RIGHT_BRACE), true, nil
+    case ',':
+        return scanner.makeToken(COMMA), true, nil
+    case '.':
+        return scanner.makeToken(DOT), true, nil
+    case '-':
+        return scanner.makeToken(MINUS), true, nil
+    case '+':
+        return scanner.makeToken(PLUS), true, nil
+    case ';':
+        return scanner.makeToken(SEMICOLON), true, nil
+    case '*':
+        return scanner.makeToken(STAR), true, nil
+    case '!':
+        if scanner.match('=') {
+            return scanner.makeToken(BANG_EQUAL), true, nil
+        }
+        return scanner.makeToken(BANG), true, nil
+    case '=':
+        if scanner.match('=') {
+            return scanner.makeToken(EQUAL_EQUAL), true, nil
+        }
+        return scanner.makeToken(EQUAL), true, nil
+    case '<':
+        if scanner.match('=') {
+            return scanner.makeToken(LESS_EQUAL), true, nil
+        }
+        return scanner.makeToken(LESS), true, nil
+    case '>':
+        if scanner.match('=') {
+            return scanner.makeToken(GREATER_EQUAL), true, nil
+        }
+        return scanner.makeToken(GREATER), true, nil
+    case '/':
+        if scanner.match('/') {
+            for scanner.peek() != '\n' && !scanner.isAtEnd() {
+                scanner.advance()
+                // Comments are dropped, so there is no need to buffer them.
+                scanner.start = scanner.current
+            }
+            return Token{}, false, nil
+        }
+        return scanner.makeToken(SLASH), true, nil
+    case ' ', '\r', '\t':
+        return Token{}, false, nil
+    case '\n':
+        scanner.line++
+        return Token{}, false, nil
+    case '"':
+        return scanner.string()
     }
 
-    isAlphaNumeric := func(c rune) bool {
-        return isAlpha(c) || isDigit(c)
+    if isDigit(c) {
+        return scanner.number()
+    } else if isAlpha(c) {
+        return scanner.identifier(), true, nil
     }
+    return Token{}, false, ScanError{scanner.line, "Unexpected character."}
+}
 
-    identifier := func() {
-        for isAlphaNumeric(peek()) {
-            advance()
+func (scanner *Scanner) string() (Token, bool, error) {
+    for scanner.peek() != '"' && !scanner.isAtEnd() {
+        if scanner.peek() == '\n' {
+            scanner.line++
         }
+        scanner.advance()
+    }
 
-        text := string(runes[start: current])
-        tokenType, isFound := keywords[text]
-
-        if !isFound {
-            tokenType = IDENTIFIER
-        }
-        tokens = append(tokens, Token{ tokenType, text, "", line })
+    if scanner.isAtEnd() {
+        return Token{}, false, nil
     }
 
-    scanToken := func() error {
-        c := advance()
+    scanner.advance()
 
-        switch c {
-        case '(':
-            {
-                addToken(LEFT_PAREN)
-                break
-            }
-        case ')':
-            {
-                addToken(RIGHT_PAREN)
-                break
-            }
-        case '{':
-            {
-                addToken(LEFT_BRACE)
-                break
-            }
-        case '}':
-            {
-                addToken(RIGHT_BRACE)
-                break
-            }
-        case ',':
-            {
-                addToken(COMMA)
-                break
-            }
-        case '.':
-            {
-                addToken(DOT)
-                break
-            }
-        case '-':
-            {
-                addToken(MINUS)
-                break
-            }
-        case '+':
-            {
-                addToken(PLUS)
-                break
-            }
-        case ';':
-            {
-                addToken(SEMICOLON)
-                break
-            }
-        case '*':
-            {
-                addToken(STAR)
-                break
-            }
-        case '!':
-            {
-                if match('=') {
-                    addToken(BANG_EQUAL)
-                } else {
-                    addToken(BANG)
-                }
-                break
-            }
-        case '=':
-            {
-                if match('=') {
-                    addToken(EQUAL_EQUAL)
-                } else {
-                    addToken(EQUAL)
-                }
-                break
-            }
-        case '<':
-            {
-                if match('=') {
-                    addToken(LESS_EQUAL)
-                } else {
-                    addToken(LESS)
-                }
-                break
-            }
-        case '>':
-            {
-                if match('=') {
-                    addToken(GREATER_EQUAL)
-                } else {
-                    addToken(GREATER)
-                }
-                break
-            }
-        case '/':
-            {
-                if match('/') {
-                    for ;peek() != '\n' && !isAtEnd(); {
-                        advance()
-                    }
-                } else {
-                    addToken(SLASH)
-                }
-                break
-            }
-        case ' ':
-        case '\r':
-        case '\t':
-            {
-                break
-            }
-        case '\n':
-            {
-                line++
-                break
-            }
-        case '"':
-            {
-                tokenizeString()
-                break
-            }
-        default:
-            {
-                if isDigit(c) {
-                    number()
-                } else if isAlpha(c) {
-                    identifier()
-                } else {
-                    log.Fatal("Unexpected Character")
-                }
-                break
-            }
-
-    }
+    value := scanner.source[scanner.start + 1:scanner.current - 1]
+    return scanner.literalToken(STRING, StringVal(value)), true, nil
+}
 
-        return nil;
+func (scanner *Scanner) number() (Token, bool, error) {
+    for isDigit(scanner.peek()) {
+        scanner.advance()
     }
 
-    for !isAtEnd() {
-        start = current
-        if err := scanToken(); err != nil {
-            return nil, err
+    if scanner.peek() == '.' && isDigit(scanner.peekNext()) {
+        scanner.advance()
+        for isDigit(scanner.peek()) {
+            scanner.advance()
         }
     }
 
-    tokens = append(tokens, Token{ EOF, "", "", line })
-    
-    return tokens, nil
+    number, err := strconv.ParseFloat(scanner.lexeme(), 64)
+    if err != nil {
+        return Token{}, false, ScanError{scanner.line, "Invalid number."}
+    }
+    return scanner.literalToken(NUMBER, NumberVal(number)), true, nil
 }
 
+func (scanner *Scanner) identifier() Token {
+    for isAlphaNumeric(scanner.peek()) {
+        scanner.advance()
+    }
+
+    // Indexing a map with a substring does not allocate.
+    tokenType, isFound := keywords[scanner.lexeme()]
+
+    if !isFound {
+        tokenType = IDENTIFIER
+    }
+    return scanner.makeToken(tokenType)
+}
@@ -1,7 +1,11 @@
 package lox
 
 import (
+    "errors"
+    "io"
+    "strings"
     "testing"
+    "testing/iotest"
 )
 
 type test struct {
@@ -16,240 +20,240 @@ func TestScan(t *testing.T) {
             name: "decimal add decimal",
             input: "1 + 1",
             expected: []Token{
-                {tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-                {tokenType: PLUS, lexeme: "+", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-                {tokenType: EOF, lexeme: "", literal: "", line: 1},
+                {tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+                {tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+                {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
             },
         },
         {
             name: "decimal minus decimal",
             input: "10 - 10",
             expected: []Token{
-                {tokenType: NUMBER, lexeme: "10", literal: 10.0, line: 1},
-                {tokenType: MINUS, lexeme: "-", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme: "10", literal: 10.0, line: 1},
-                {tokenType: EOF, lexeme: "", literal: "", line: 1},
+                {tokenType: NUMBER, lexeme: "10", literal: NumberVal(10.0), line: 1},
+                {tokenType: MINUS, lexeme: "-", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme: "10", literal: NumberVal(10.0), line: 1},
+                {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
             },
         },
         {
             name: "decimal multiply decimal",
             input: "2 * 20",
             expected: []Token{
-                {tokenType: NUMBER, lexeme: "2", literal: 2.0, line: 1},
-                {tokenType: STAR, lexeme: "*", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme: "20", literal: 20.0, line: 1},
-                {tokenType: EOF, lexeme: "", literal: "", line: 1},
+                {tokenType: NUMBER, lexeme: "2", literal: NumberVal(2.0), line: 1},
+                {tokenType: STAR, lexeme: "*", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme: "20", literal: NumberVal(20.0), line: 1},
+                {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
             },
         },
         {
             name: "decimal divide decimal",
             input: "13 / 10",
             expected: []Token{
-                {tokenType: NUMBER, lexeme: "13", literal: 13.0, line: 1},
-                {tokenType: SLASH, lexeme: "/", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme: "10", literal: 10.0, line: 1},
-                {tokenType: EOF, lexeme: "", literal: "", line: 1},
+                {tokenType: NUMBER, lexeme: "13", literal: NumberVal(13.0), line: 1},
+                {tokenType: SLASH, lexeme: "/", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme: "10", literal: NumberVal(10.0), line: 1},
+                {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
             },
         },
         {
             name: "flaot divide decimal",
             input: "13.0001 / 10",
             expected: []Token{
-                {tokenType: NUMBER, lexeme: "13.0001", literal: 13.0001, line: 1},
-                {tokenType: SLASH, lexeme: "/", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme: "10", literal: 10.0, line: 1},
-                {tokenType: EOF, lexeme: "", literal: "", line: 1},
+                {tokenType: NUMBER, lexeme: "13.0001", literal: NumberVal(13.0001), line: 1},
+                {tokenType: SLASH, lexeme: "/", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme: "10", literal: NumberVal(10.0), line: 1},
+                {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
             },
         },
         {
             name: "grouped addition on decimals divide decimal",
             input: "(1 + 1) / 10",
             expected: []Token{
-                {tokenType: LEFT_PAREN, lexeme: "(", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-                {tokenType: PLUS, lexeme: "+", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-                {tokenType: RIGHT_PAREN, lexeme: ")", literal: "", line: 1},
-                {tokenType: SLASH, lexeme: "/", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme: "10", literal: 10.0, line: 1},
-                {tokenType: EOF, lexeme: "", literal: "", line: 1},
+                {tokenType: LEFT_PAREN, lexeme: "(", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+                {tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+                {tokenType: RIGHT_PAREN, lexeme: ")", literal: StringVal(""), line: 1},
+                {tokenType: SLASH, lexeme: "/", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme: "10", literal: NumberVal(10.0), line: 1},
+                {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
             },
         },
         {
             name: "is decimal equal decimal",
             input: "1 == 1",
             expected: []Token{
-                {tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-                {tokenType: EQUAL_EQUAL, lexeme: "==", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-                {tokenType: EOF, lexeme: "", literal: "", line: 1},
+                {tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+                {tokenType: EQUAL_EQUAL, lexeme: "==", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+                {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
             },
         },
         {
             name: "is decimal not equal decimal",
             input: "1 != 1",
             expected: []Token{
-                {tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-                {tokenType: BANG_EQUAL, lexeme: "!=", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-                {tokenType: EOF, lexeme: "", literal: "", line: 1},
+                {tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+                {tokenType: BANG_EQUAL, lexeme: "!=", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+                {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
             },
         },
         {
             name: "normal string expression",
             input: `"brown fox"`,
             expected: []Token{
-                {tokenType: STRING, lexeme:  `"brown fox"`, literal: "brown fox", line: 1},
-                {tokenType: EOF, lexeme: "", literal: "", line: 1},
+                {tokenType: STRING, lexeme:  `"brown fox"`, literal: StringVal("brown fox"), line: 1},
+                {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
             },
         },
         {
             name: "string expression with comma within",
             input: `"brown, fox"`,
             expected: []Token{
-                {tokenType: STRING, lexeme:  `"brown, fox"`, literal: "brown, fox", line: 1},
-                {tokenType: EOF, lexeme: "", literal: "", line: 1},
+                {tokenType: STRING, lexeme:  `"brown, fox"`, literal: StringVal("brown, fox"), line: 1},
+                {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
             },
         },
         {
             name: "decimal plus decimal in quotation",
             input: `"1 + 1"`,
             expected: []Token{
-                {tokenType: STRING, lexeme:  `"1 + 1"`, literal: "1 + 1", line: 1},
-                {tokenType: EOF, lexeme: "", literal: "", line: 1},
+                {tokenType: STRING, lexeme:  `"1 + 1"`, literal: StringVal("1 + 1"), line: 1},
+                {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
             },
         },
         {
             name: "two greater than one",
             input: "2 > 1",
             expected: []Token{
-                {tokenType: NUMBER, lexeme:  "2", literal: 2.0, line: 1},
-                {tokenType: GREATER, lexeme:  ">", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme:  "1", literal: 1.0, line: 1},
-                {tokenType: EOF, lexeme: "", literal: "", line: 1},
+                {tokenType: NUMBER, lexeme:  "2", literal: NumberVal(2.0), line: 1},
+                {tokenType: GREATER, lexeme:  ">", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme:  "1", literal: NumberVal(1.0), line: 1},
+                {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
             },
         },
         {
             name: "two greater than or equal to one",
             input: "2 >= 1",
             expected: []Token{
-                {tokenType: NUMBER, lexeme:  "2", literal: 2.0, line: 1},
-                {tokenType: GREATER_EQUAL, lexeme:  ">=", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme:  "1", literal: 1.0, line: 1},
-                {tokenType: EOF, lexeme: "", literal: "", line: 1},
+                {tokenType: NUMBER, lexeme:  "2", literal: NumberVal(2.0), line: 1},
+                {tokenType: GREATER_EQUAL, lexeme:  ">=", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme:  "1", literal: NumberVal(1.0), line: 1},
+                {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
             },
         },
         {
             name: "one less two",
             input: "1 < 2",
             expected: []Token{
-                {tokenType: NUMBER, lexeme:  "1", literal: 1.0, line: 1},
-                {tokenType: LESS, lexeme:  "<", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme:  "2", literal: 2.0, line: 1},
-                {tokenType: EOF, lexeme: "", literal: "", line: 1},
+                {tokenType: NUMBER, lexeme:  "1", literal: NumberVal(1.0), line: 1},
+                {tokenType: LESS, lexeme:  "<", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme:  "2", literal: NumberVal(2.0), line: 1},
+                {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
             },
         },
         {
             name: "one less than or equal to two",
             input: "1 <= 2",
             expected: []Token{
-                {tokenType: NUMBER, lexeme:  "1", literal: 1.0, line: 1},
-                {tokenType: LESS_EQUAL, lexeme:  "<=", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme:  "2", literal: 2.0, line: 1},
-                {tokenType: EOF, lexeme: "", literal: "", line: 1},
+                {tokenType: NUMBER, lexeme:  "1", literal: NumberVal(1.0), line: 1},
+                {tokenType: LESS_EQUAL, lexeme:  "<=", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme:  "2", literal: NumberVal(2.0), line: 1},
+                {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
             },
         },
         {
             name: "number assigned to a variable called foo",
             input: "foo = 1234",
             expected: []Token{
-                {tokenType: IDENTIFIER, lexeme: "foo", literal: "", line: 1},
-                {tokenType: EQUAL, lexeme: "=", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme: "1234", literal: 1234.0, line: 1},
-                {tokenType: EOF, lexeme: "", literal: "", line: 1},
+                {tokenType: IDENTIFIER, lexeme: "foo", literal: StringVal(""), line: 1},
+                {tokenType: EQUAL, lexeme: "=", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme: "1234", literal: NumberVal(1234.0), line: 1},
+                {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
             },
         },
         {
             name: "negate equality expression",
             input: "!(1 == 1)",
             expected: []Token{
-                {tokenType: BANG, lexeme: "!", literal: "", line: 1},
-                {tokenType: LEFT_PAREN, lexeme: "(", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-                {tokenType: EQUAL_EQUAL, lexeme: "==", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-                {tokenType: RIGHT_PAREN, lexeme: ")", literal: "", line: 1},
-                {tokenType: EOF, lexeme: "", literal: "", line: 1},
+                {tokenType: BANG, lexeme: "!", literal: StringVal(""), line: 1},
+                {tokenType: LEFT_PAREN, lexeme: "(", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+                {tokenType: EQUAL_EQUAL, lexeme: "==", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+                {tokenType: RIGHT_PAREN, lexeme: ")", literal: StringVal(""), line: 1},
+                {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
             },
         },
         {
             name: "negate number",
             input: "-1",
             expected: []Token{
-                {tokenType: MINUS, lexeme: "-", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-                {tokenType: EOF, lexeme: "", literal: "", line: 1},
+                {tokenType: MINUS, lexeme: "-", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+                {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
             },
         },
         {
             name: "expression end in semicolon",
             input: "1 + 1;",
             expected: []Token{
-                {tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-                {tokenType: PLUS, lexeme: "+", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-                {tokenType: SEMICOLON, lexeme: ";", literal: "", line: 1},
-                {tokenType: EOF, lexeme: "", literal: "", line: 1},
+                {tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+                {tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+                {tokenType: SEMICOLON, lexeme: ";", literal: StringVal(""), line: 1},
+                {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
             },
         },
         {
             name: "comma seperated numbers",
             input: "1, 2, 3",
             expected: []Token{
-                {tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-                {tokenType: COMMA, lexeme: ",", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme: "2", literal: 2.0, line: 1},
-                {tokenType: COMMA, lexeme: ",", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme: "3", literal: 3.0, line: 1},
-                {tokenType: EOF, lexeme: "", literal: "", line: 1},
+                {tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+                {tokenType: COMMA, lexeme: ",", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme: "2", literal: NumberVal(2.0), line: 1},
+                {tokenType: COMMA, lexeme: ",", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme: "3", literal: NumberVal(3.0), line: 1},
+                {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
             },
         },
         {
             name: "scoped expression in braces in single line",
             input: "{1 + 1}",
             expected: []Token{
-                {tokenType: LEFT_BRACE, lexeme: "{", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-                {tokenType: PLUS, lexeme: "+", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme: "1", literal: 1.0, line: 1},
-                {tokenType: RIGHT_BRACE, lexeme: "}", literal: "", line: 1},
-                {tokenType: EOF, lexeme: "", literal: "", line: 1},
+                {tokenType: LEFT_BRACE, lexeme: "{", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+                {tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 1},
+                {tokenType: RIGHT_BRACE, lexeme: "}", literal: StringVal(""), line: 1},
+                {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
             },
         },
         {
             name: "access object properties using dot operator",
             input: "foo.bar",
             expected: []Token{
-                {tokenType: IDENTIFIER, lexeme: "foo", literal: "", line: 1},
-                {tokenType: DOT, lexeme: ".", literal: "", line: 1},
-                {tokenType: IDENTIFIER, lexeme: "bar", literal: "", line: 1},
-                {tokenType: EOF, lexeme: "", literal: "", line: 1},
+                {tokenType: IDENTIFIER, lexeme: "foo", literal: StringVal(""), line: 1},
+                {tokenType: DOT, lexeme: ".", literal: StringVal(""), line: 1},
+                {tokenType: IDENTIFIER, lexeme: "bar", literal: StringVal(""), line: 1},
+                {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 1},
             },
         },
         {
             name: "expressions in new line",
             input: "foo = 1234;\nbar = 4321;",
             expected: []Token{
-                {tokenType: IDENTIFIER, lexeme: "foo", literal: "", line: 1},
-                {tokenType: EQUAL, lexeme: "=", literal: "", line: 1},
-                {tokenType: NUMBER, lexeme: "1234", literal: 1234.0, line: 1},
-                {tokenType: SEMICOLON, lexeme: ";", literal: "", line: 1},
-                {tokenType: IDENTIFIER, lexeme: "bar", literal: "", line: 2},
-                {tokenType: EQUAL, lexeme: "=", literal: "", line: 2},
-                {tokenType: NUMBER, lexeme: "4321", literal: 4321.0, line: 2},
-                {tokenType: SEMICOLON, lexeme: ";", literal: "", line: 2},
-                {tokenType: EOF, lexeme: "", literal: "", line: 2},
+                {tokenType: IDENTIFIER, lexeme: "foo", literal: StringVal(""), line: 1},
+                {tokenType: EQUAL, lexeme: "=", literal: StringVal(""), line: 1},
+                {tokenType: NUMBER, lexeme: "1234", literal: NumberVal(1234.0), line: 1},
+                {tokenType: SEMICOLON, lexeme: ";", literal: StringVal(""), line: 1},
+                {tokenType: IDENTIFIER, lexeme: "bar", literal: StringVal(""), line: 2},
+                {tokenType: EQUAL, lexeme: "=", literal: StringVal(""), line: 2},
+                {tokenType: NUMBER, lexeme: "4321", literal: NumberVal(4321.0), line: 2},
+                {tokenType: SEMICOLON, lexeme: ";", literal: StringVal(""), line: 2},
+                {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 2},
             },
         },
     }
@@ -265,10 +269,121 @@ func TestScan(t *testing.T) {
             if !isEqual {
                 t.Errorf("Result was incorrect,\n got result:\n %s,\n expected:\n%s\n", result, testCase.expected)
             }
+
+            for _, token := range result {
+                if testCase.input[token.Offset():token.End()] != token.lexeme {
+                    t.Errorf("Incorrect offset %d for lexeme %q.\n", token.Offset(), token.lexeme)
+                }
+            }
+
+            streamed := scanAll(t, NewScanner(iotest.OneByteReader(strings.NewReader(testCase.input))))
+            if !areTokenArrsEqual(streamed, testCase.expected) {
+                t.Errorf("Streaming result was incorrect,\n got result:\n %s,\n expected:\n%s\n", streamed, testCase.expected)
+            }
         })
     }
  }
 
+func scanAll(t *testing.T, scanner *Scanner) []Token {
+    tokens := make([]Token, 0)
+    for {
+        token, err := scanner.Next()
+        if err != nil {
+            t.Fatalf("scanning failed: %v\n", err)
+        }
+        tokens = append(tokens, token)
+        if token.tokenType == EOF {
+            return tokens
+        }
+    }
+}
+
+func TestScanAllocations(t *testing.T) {
+    source := strings.Repeat("(1.5 + \"s\" != !false) == nil and foo or bar >= ", 20) + "baz"
+    tokens, err := Scan(source)
+    if err != nil {
+        t.Fatalf("scanning failed: %v\n", err)
+    }
+
+    // Only the token slice itself is allocated: lexemes and strings share
+    // the source and numbers are stored inline.
+    allocs := testing.AllocsPerRun(10, func() {
+        Scan(source)
+    })
+    growth := testing.AllocsPerRun(10, func() {
+        tokenSink = nil
+        for range tokens {
+            tokenSink = append(tokenSink, Token{})
+        }
+    })
+    if allocs > growth {
+        t.Errorf("Scanning %d tokens allocated %v times, expected at most %v.\n", len(tokens), allocs, growth)
+    }
+}
+
+// tokenSink keeps the slice grown by TestScanAllocations on the heap, like
+// the one Scan returns.
+var tokenSink []Token
+
+func TestScannerLargeInput(t *testing.T) {
+    long := strings.Repeat("x", 3 * scannerBufferSize)
+    input := "// " + long + "\n\"" + long + "\" + 1"
+
+    tokens := scanAll(t, NewScanner(strings.NewReader(input)))
+    expected := []Token{
+        {tokenType: STRING, lexeme: "\"" + long + "\"", literal: StringVal(long), line: 2},
+        {tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 2},
+        {tokenType: NUMBER, lexeme: "1", literal: NumberVal(1.0), line: 2},
+        {tokenType: EOF, lexeme: "", literal: StringVal(""), line: 2},
+    }
+
+    if !areTokenArrsEqual(tokens, expected) {
+        t.Errorf("Result was incorrect,\n got result:\n %s,\n expected:\n%s\n", tokens, expected)
+    }
+}
+
+func TestScannerErrors(t *testing.T) {
+    tests := []struct {
+        name string
+        reader func() io.Reader
+        expected string
+    } {
+        {
+            name: "unexpected character",
+            reader: func() io.Reader { return strings.NewReader("1 +\n@") },
+            expected: "[line 2] Error: Unexpected character.",
+        },
+        {
+            name: "read error",
+            reader: func() io.Reader { return iotest.ErrReader(errors.New("connection reset")) },
+            expected: "connection reset",
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            scanner := NewScanner(test.reader())
+
+            var err error
+            for err == nil {
+                var token Token
+                token, err = scanner.Next()
+                if token.tokenType == EOF {
+                    break
+                }
+            }
+
+            if err == nil || err.Error() != test.expected {
+                t.Errorf("Incorrect error.\nresult  :%v\nexpected:%s\n", err, test.expected)
+            }
+
+            if _, err := ParseFrom(NewScanner(test.reader())); err == nil || err.Error() != test.expected {
+                t.Errorf("Incorrect parse error.\nresult  :%v\nexpected:%s\n", err, test.expected)
+            }
+        })
+    }
+}
+
 func areTokenArrsEqual(tokenArr1, tokenArr2 []Token) bool {
     if len(tokenArr1) != len(tokenArr2) {
         return false
@@ -289,3 +404,36 @@ func areTokenEqual(token1, token2 Token) bool {
     }
     return false
 }
+
+// benchmarkInputs are representative programs shared by the Scan, Parse and
+// Interpret benchmarks.
+var benchmarkInputs = []struct {
+    name string
+    source string
+} {
+    {
+        name: "arithmetic",
+        source: benchmarkSource,
+    },
+    {
+        name: "strings",
+        source: strings.Repeat("\"the quick brown fox\" + 12.5 + \" jumps\" + ", 50) + "\"\"",
+    },
+    {
+        name: "multiline with comments",
+        source: strings.Repeat("// running total\n(1.25 + 2.5) * -3 >= 4 ==\n", 50) + "true",
+    },
+}
+
+func BenchmarkScan(b *testing.B) {
+    for _, input := range benchmarkInputs {
+        b.Run(input.name, func(b *testing.B) {
+            b.ReportAllocs()
+            b.SetBytes(int64(len(input.source)))
+
+            for i := 0; i < b.N; i++ {
+                Scan(input.source)
+            }
+        })
+    }
+}
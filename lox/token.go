@@ -129,10 +129,41 @@ var keywords = map[string]TokenType{
 type Token struct {
     tokenType TokenType
     lexeme string
-    literal any
+    literal Value
     line int
+    // offset is the byte offset of the lexeme in the scanned source.
+    offset int
 }
 
 func (token Token) String() string {
-    return fmt.Sprintf("tokenType: %v, lexeme: %s, literal: %v, line: %d\n", token.tokenType, token.lexeme, token.literal, token.line)
+    return fmt.Sprintf("tokenType: %v, lexeme: %s, literal: %v, line: %d\n", token.tokenType, token.lexeme, token.literal.Any(), token.line)
+}
+
+func NewToken(tokenType TokenType, lexeme string, literal any, line int) Token {
+    return Token{tokenType, lexeme, ValueOf(literal), line, 0}
+}
+
+func (token Token) Type() TokenType {
+    return token.tokenType
+}
+
+func (token Token) Lexeme() string {
+    return token.lexeme
+}
+
+func (token Token) Literal() any {
+    return token.literal.Any()
+}
+
+func (token Token) Line() int {
+    return token.line
+}
+
+func (token Token) Offset() int {
+    return token.offset
+}
+
+// End returns the byte offset just past the lexeme in the scanned source.
+func (token Token) End() int {
+    return token.offset + len(token.lexeme)
 }
@@ -10,22 +10,27 @@ func TestString(t *testing.T) {
     } {
         {
             name: "identifier literal",
-            input: Token {tokenType: IDENTIFIER, lexeme: "foo", literal: "foo", line: 1},
+            input: Token {tokenType: IDENTIFIER, lexeme: "foo", literal: StringVal("foo"), line: 1},
             expected: "tokenType: IDENTIFIER, lexeme: foo, literal: foo, line: 1\n",
         },
         {
             name: "string literal",
-            input: Token {tokenType: STRING, lexeme: "\"temp string\"", literal: "temp string", line: 1},
+            input: Token {tokenType: STRING, lexeme: "\"temp string\"", literal: StringVal("temp string"), line: 1},
             expected: "tokenType: STRING, lexeme: \"temp string\", literal: temp string, line: 1\n",
         },
         {
             name: "number literal",
-            input: Token {tokenType: NUMBER, lexeme: "1234.4321", literal: "1234.4321", line: 1},
+            input: Token {tokenType: NUMBER, lexeme: "1234.4321", literal: NumberVal(1234.4321), line: 1},
             expected: "tokenType: NUMBER, lexeme: 1234.4321, literal: 1234.4321, line: 1\n",
         },
+        {
+            name: "no literal",
+            input: Token {tokenType: EOF, lexeme: "", line: 1},
+            expected: "tokenType: EOF, lexeme: , literal: <nil>, line: 1\n",
+        },
         {
             name: "non-literal",
-            input: Token {tokenType: COMMA, lexeme: ",", literal: "", line: 1},
+            input: Token {tokenType: COMMA, lexeme: ",", literal: StringVal(""), line: 1},
             expected: "tokenType: COMMA, lexeme: ,, literal: , line: 1\n",
         },
     }
@@ -0,0 +1,124 @@
+package lox
+
+import (
+    "fmt"
+    "strconv"
+)
+
+type ValueKind uint8
+
+const (
+    NilValue ValueKind = iota
+    BoolValue
+    NumberValue
+    StringValue
+)
+
+// Value is a Lox runtime value. Numbers and booleans are stored inline, so
+// unlike an any holding a float64 they never allocate.
+type Value struct {
+    kind ValueKind
+    number float64
+    str string
+}
+
+func NilVal() Value {
+    return Value{}
+}
+
+func BoolVal(b bool) Value {
+    if b {
+        return Value{kind: BoolValue, number: 1}
+    }
+    return Value{kind: BoolValue}
+}
+
+func NumberVal(number float64) Value {
+    return Value{kind: NumberValue, number: number}
+}
+
+func StringVal(s string) Value {
+    return Value{kind: StringValue, str: s}
+}
+
+// ValueOf converts a literal value to a Value. Go values other than nil,
+// bool, float64 and string behave like strings at runtime, so they become
+// their formatted string.
+func ValueOf(value any) Value {
+    switch value := value.(type) {
+    case nil:
+        return NilVal()
+    case bool:
+        return BoolVal(value)
+    case float64:
+        return NumberVal(value)
+    case string:
+        return StringVal(value)
+    }
+    return StringVal(fmt.Sprint(value))
+}
+
+func (value Value) Kind() ValueKind {
+    return value.kind
+}
+
+func (value Value) IsNumber() bool {
+    return value.kind == NumberValue
+}
+
+func (value Value) AsNumber() float64 {
+    return value.number
+}
+
+func (value Value) AsBool() bool {
+    return value.number != 0
+}
+
+func (value Value) AsString() string {
+    return value.str
+}
+
+// Any converts the value back to the nil, bool, float64 or string it holds.
+func (value Value) Any() any {
+    switch value.kind {
+    case BoolValue:
+        return value.AsBool()
+    case NumberValue:
+        return value.number
+    case StringValue:
+        return value.str
+    }
+    return nil
+}
+
+func (value Value) isTruthy() bool {
+    switch value.kind {
+    case NilValue:
+        return false
+    case BoolValue:
+        return value.AsBool()
+    }
+    return true
+}
+
+// format renders the value the way fmt's %v renders the Go value it holds,
+// which is what string concatenation has always used.
+func (value Value) format() string {
+    switch value.kind {
+    case NilValue:
+        return "<nil>"
+    case BoolValue:
+        return strconv.FormatBool(value.AsBool())
+    case NumberValue:
+        return strconv.FormatFloat(value.number, 'g', -1, 64)
+    }
+    return value.str
+}
+
+// String formats the value the way Lox prints it.
+func (value Value) String() string {
+    if value.kind == NilValue {
+        return "nil"
+    }
+    return value.format()
+}
@@ -0,0 +1,40 @@
+package lox
+
+import (
+    "fmt"
+    "math"
+    "testing"
+)
+
+func TestValueRoundTrip(t *testing.T) {
+    inputs := []any{nil, true, false, 0.0, -1.5, math.Inf(1), "", "string"}
+
+    for _, input := range inputs {
+        t.Run(fmt.Sprint(input), func(t *testing.T) {
+            result := ValueOf(input).Any()
+            if result != input {
+                t.Errorf("Incorrect result.\nresult  :%#v\nexpected:%#v\n", result, input)
+            }
+        })
+    }
+}
+
+// Concatenation used to format operands with %v, so format has to match it
+// exactly.
+func TestValueFormat(t *testing.T) {
+    inputs := []any{
+        nil, true, false, "text",
+        0.0, math.Copysign(0, -1), 1.0, -1.5, 0.1, 1.0 / 3, 100000.0, 1e20, 1e21, 1e-4, 1e-5,
+        123456789.0, 1.7976931348623157e308, 5e-324, math.Inf(1), math.Inf(-1), math.NaN(),
+    }
+
+    for _, input := range inputs {
+        expected := fmt.Sprintf("%v", input)
+        t.Run(expected, func(t *testing.T) {
+            result := ValueOf(input).format()
+            if result != expected {
+                t.Errorf("Incorrect result.\nresult  :%s\nexpected:%s\n", result, expected)
+            }
+        })
+    }
+}
@@ -0,0 +1,140 @@
+package lox
+
+import (
+    "errors"
+    "fmt"
+    "io"
+)
+
+// VM is a stack machine executing chunks produced by Compile. It gives the
+// same results and runtime errors as Interpret. A VM can be reused for
+// several chunks but not concurrently.
+type VM struct {
+    chunk *Chunk
+    ip int
+    stack []Value
+
+    // Trace, when set, receives the value stack and the disassembled
+    // instruction before each instruction is executed.
+    Trace io.Writer
+}
+
+func NewVM() *VM {
+    return &VM{stack: make([]Value, 0, 256)}
+}
+
+func (vm *VM) push(value Value) {
+    vm.stack = append(vm.stack, value)
+}
+
+func (vm *VM) pop() Value {
+    value := vm.stack[len(vm.stack) - 1]
+    vm.stack = vm.stack[:len(vm.stack) - 1]
+    return value
+}
+
+func (vm *VM) readByte() byte {
+    vm.ip++
+    return vm.chunk.code[vm.ip - 1]
+}
+
+// runtimeError reports a failure of the instruction that starts at offset.
+func (vm *VM) runtimeError(offset int, message string) error {
+    if wrapped, ok := vm.chunk.errorMessages[offset]; ok {
+        message = wrapped
+    }
+    return errors.New(message)
+}
+
+// Run executes chunk and returns the value it leaves on the stack.
+func (vm *VM) Run(chunk *Chunk) (any, error) {
+    vm.chunk = chunk
+    vm.ip = 0
+    vm.stack = vm.stack[:0]
+
+    for {
+        offset := vm.ip
+        if vm.Trace != nil {
+            vm.traceInstruction(offset)
+        }
+        instruction := OpCode(vm.readByte())
+
+        switch instruction {
+        case OP_CONSTANT:
+            vm.push(chunk.constants[vm.readByte()])
+        case OP_CONSTANT_LONG:
+            index := int(vm.readByte()) << 16 | int(vm.readByte()) << 8 | int(vm.readByte())
+            vm.push(chunk.constants[index])
+        case OP_NIL:
+            vm.push(NilVal())
+        case OP_TRUE:
+            vm.push(BoolVal(true))
+        case OP_FALSE:
+            vm.push(BoolVal(false))
+        case OP_NOT:
+            vm.push(BoolVal(!vm.pop().isTruthy()))
+        case OP_NEGATE:
+            value := vm.pop()
+            if !value.IsNumber() {
+                return nil, vm.runtimeError(offset, notANumberMessage)
+            }
+            vm.push(NumberVal(-value.AsNumber()))
+        case OP_EQUAL, OP_NOT_EQUAL, OP_ADD:
+            right := vm.pop()
+            left := vm.pop()
+            bothNumbers := left.IsNumber() && right.IsNumber()
+
+            switch {
+            case instruction == OP_EQUAL && bothNumbers:
+                vm.push(BoolVal(left.AsNumber() == right.AsNumber()))
+            case instruction == OP_EQUAL:
+                vm.push(BoolVal(left.isTruthy() == right.isTruthy()))
+            case instruction == OP_NOT_EQUAL && bothNumbers:
+                vm.push(BoolVal(left.AsNumber() != right.AsNumber()))
+            case instruction == OP_NOT_EQUAL:
+                vm.push(BoolVal(left.isTruthy() != right.isTruthy()))
+            case bothNumbers:
+                vm.push(NumberVal(left.AsNumber() + right.AsNumber()))
+            default:
+                vm.push(StringVal(left.format() + right.format()))
+            }
+        case OP_GREATER, OP_GREATER_EQUAL, OP_LESS, OP_LESS_EQUAL, OP_SUBTRACT, OP_MULTIPLY, OP_DIVIDE:
+            rightValue := vm.pop()
+            leftValue := vm.pop()
+            if !leftValue.IsNumber() || !rightValue.IsNumber() {
+                return nil, vm.runtimeError(offset, notANumberMessage)
+            }
+            left, right := leftValue.AsNumber(), rightValue.AsNumber()
+
+            switch instruction {
+            case OP_GREATER:
+                vm.push(BoolVal(left > right))
+            case OP_GREATER_EQUAL:
+                vm.push(BoolVal(left >= right))
+            case OP_LESS:
+                vm.push(BoolVal(left < right))
+            case OP_LESS_EQUAL:
+                vm.push(BoolVal(left <= right))
+            case OP_SUBTRACT:
+                vm.push(NumberVal(left - right))
+            case OP_MULTIPLY:
+                vm.push(NumberVal(left * right))
+            case OP_DIVIDE:
+                vm.push(NumberVal(left / right))
+            }
+        case OP_RETURN:
+            return vm.pop().Any(), nil
+        default:
+            return nil, fmt.Errorf("unknown instruction %d at offset %d", instruction, offset)
+        }
+    }
+}
+
+func (vm *VM) traceInstruction(offset int) {
+    fmt.Fprint(vm.Trace, "          ")
+    for _, value := range vm.stack {
+        fmt.Fprintf(vm.Trace, "[ %s ]", value)
+    }
+    fmt.Fprintln(vm.Trace)
+    vm.chunk.DisassembleInstruction(vm.Trace, offset)
+}
@@ -0,0 +1,150 @@
+package lox
+
+import (
+    "strings"
+    "testing"
+)
+
+func parseSource(t testing.TB, source string) Expr {
+    tokens, err := Scan(source)
+    if err != nil {
+        t.Fatalf("scanning failed: %v\n", err)
+    }
+    expression, err := Parse(tokens)
+    if err != nil {
+        t.Fatalf("parsing failed: %v\n", err)
+    }
+    return expression
+}
+
+// engineTests are evaluated by every execution engine, which must agree with
+// the tree-walker on both values and runtime errors.
+var engineTests = []string {
+    "1",
+    "nil",
+    "true",
+    "!false",
+    "!nil",
+    "!\"string\"",
+    "-1.5",
+    "--2",
+    "(1.1 + 2 - 10) * 1.10000001 / 2.24354352",
+    "1 / 0",
+    "-1 / 0",
+    "0 / 0 == 0 / 0",
+    "0 / 0 != 0 / 0",
+    "0 / 0 >= 1",
+    "1.7976931348623157e308 * 2",
+    "1 < 2",
+    "2 <= 2",
+    "1 > 2",
+    "2 >= 2",
+    "9.5 == 9.5",
+    "1 != 2",
+    "true == false",
+    "nil == false",
+    "\"a\" == \"b\"",
+    "\"string\" == true",
+    "\"a\" != nil",
+    "\"hello\" + \", world!\"",
+    "\"\" + 1",
+    "1 + 1 + \"1\"",
+    "\"1\" + 1 + 1",
+    "nil + true",
+    "-\"muffin\"",
+    "-nil",
+    "1 - \"x\"",
+    "\"x\" * 2",
+    "true < false",
+    "-\"x\" + 1",
+    "1 + -\"x\"",
+    "-(-\"x\")",
+    "(1 + (2 * -\"x\"))",
+    "!(1 + (2 * -\"x\"))",
+    "1 + 2 * (3 - (4 / (5 - -\"x\")))",
+}
+
+func TestVM(t *testing.T) {
+    vm := NewVM()
+
+    for _, source := range engineTests {
+        t.Run(source, func(t *testing.T) {
+            expression := parseSource(t, source)
+            expected, expectedErr := Interpret(expression)
+
+            chunk, err := Compile(expression)
+            if err != nil {
+                t.Fatalf("compiling failed: %v\n", err)
+            }
+            result, err := vm.Run(chunk)
+
+            assertSameResult(t, result, err, expected, expectedErr)
+        })
+    }
+}
+
+func TestClosures(t *testing.T) {
+    for _, source := range engineTests {
+        t.Run(source, func(t *testing.T) {
+            expression := parseSource(t, source)
+            expected, expectedErr := Interpret(expression)
+            result, err := CompileClosures(expression)()
+
+            assertSameResult(t, result, err, expected, expectedErr)
+        })
+    }
+}
+
+func TestCompileManyConstants(t *testing.T) {
+    source := "0" + strings.Repeat(" + 1", 300)
+
+    chunk, err := Compile(parseSource(t, source))
+    if err != nil {
+        t.Fatalf("compiling failed: %v\n", err)
+    }
+    if len(chunk.constants) != 301 {
+        t.Errorf("expected 301 constants, got %d\n", len(chunk.constants))
+    }
+
+    result, err := NewVM().Run(chunk)
+    if err != nil || result != 300.0 {
+        t.Errorf("Incorrect result.\nresult  :%v %v\nexpected:%v\n", result, err, 300.0)
+    }
+}
+
+// benchmarkSource is an arithmetic-heavy expression with a few hundred nodes.
+var benchmarkSource = strings.Repeat("(1.5 * 2 - 3 / 4 + -(5 - 6) * 7 > 8 == !(9 <= 10)) != ", 20) + "true"
+
+func BenchmarkTreeWalker(b *testing.B) {
+    expression := parseSource(b, benchmarkSource)
+    b.ReportAllocs()
+    b.ResetTimer()
+
+    for i := 0; i < b.N; i++ {
+        Interpret(expression)
+    }
+}
+
+func BenchmarkVM(b *testing.B) {
+    chunk, err := Compile(parseSource(b, benchmarkSource))
+    if err != nil {
+        b.Fatal(err)
+    }
+    vm := NewVM()
+    b.ReportAllocs()
+    b.ResetTimer()
+
+    for i := 0; i < b.N; i++ {
+        vm.Run(chunk)
+    }
+}
+
+func BenchmarkClosures(b *testing.B) {
+    eval := CompileClosures(parseSource(b, benchmarkSource))
+    b.ReportAllocs()
+    b.ResetTimer()
+
+    for i := 0; i < b.N; i++ {
+        eval()
+    }
+}
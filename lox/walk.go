@@ -0,0 +1,163 @@
+package lox
+
+// A Walker's Visit method is invoked for each expression encountered by Walk.
+// If the result w is not nil, Walk visits each of the children of expr with
+// w, followed by a call of w.Visit(nil).
+type Walker interface {
+    Visit(expr Expr) (w Walker)
+}
+
+// Walk traverses an expression tree in depth-first order, like go/ast.Walk.
+func Walk(walker Walker, expr Expr) {
+    if walker = walker.Visit(expr); walker == nil {
+        return
+    }
+
+    for _, child := range children(expr) {
+        Walk(walker, child)
+    }
+
+    walker.Visit(nil)
+}
+
+type inspector func(Expr) bool
+
+func (f inspector) Visit(expr Expr) Walker {
+    if f(expr) {
+        return f
+    }
+    return nil
+}
+
+// Inspect traverses an expression tree in depth-first order, calling f(expr)
+// for each expression. If f returns true, Inspect invokes f recursively for
+// each of the children of expr, followed by a call of f(nil).
+func Inspect(expr Expr, f func(Expr) bool) {
+    Walk(inspector(f), expr)
+}
+
+type childLister struct{}
+
+var _ ExprVisitor[[]Expr] = childLister{}
+
+func children(expr Expr) []Expr {
+    result, _ := VisitExpr[[]Expr](expr, childLister{})
+    return result
+}
+
+func (lister childLister) VisitBinary(expr Binary) ([]Expr, error) {
+    return []Expr{expr.left, expr.right}, nil
+}
+
+func (lister childLister) VisitGrouping(expr Grouping) ([]Expr, error) {
+    return []Expr{expr.expression}, nil
+}
+
+func (lister childLister) VisitLiteral(expr Literal) ([]Expr, error) {
+    return nil, nil
+}
+
+func (lister childLister) VisitUnary(expr Unary) ([]Expr, error) {
+    return []Expr{expr.right}, nil
+}
+
+// A Cursor describes the expression encountered during Apply.
+type Cursor struct {
+    parent Expr
+    name string
+    expr Expr
+}
+
+// Node returns the current expression.
+func (cursor *Cursor) Node() Expr {
+    return cursor.expr
+}
+
+// Parent returns the expression containing the current one, or nil for the
+// root.
+func (cursor *Cursor) Parent() Expr {
+    return cursor.parent
+}
+
+// Name returns the name of the parent's field holding the current
+// expression, such as "Left", or "" for the root.
+func (cursor *Cursor) Name() string {
+    return cursor.name
+}
+
+// Replace replaces the current expression. Apply does not walk the
+// replacement when called from post; when called from pre it walks the
+// replacement's children.
+func (cursor *Cursor) Replace(expr Expr) {
+    cursor.expr = expr
+}
+
+// An ApplyFunc is invoked by Apply for each expression, before and after its
+// children are traversed.
+type ApplyFunc func(*Cursor) bool
+
+// Apply traverses an expression tree recursively, like astutil.Apply, and
+// returns the possibly modified tree. Expressions are values, so every
+// ancestor of a replaced expression is rebuilt and the original tree is left
+// unchanged.
+//
+// If pre is not nil it is called for each expression before its children are
+// traversed. If pre returns false, no children are traversed and post is not
+// called for that expression. If post is not nil and returns false, traversal
+// stops and Apply returns the tree as rewritten so far.
+func Apply(root Expr, pre, post ApplyFunc) Expr {
+    applier := &applier{pre: pre, post: post}
+    return applier.apply(nil, "", root)
+}
+
+type applier struct {
+    pre ApplyFunc
+    post ApplyFunc
+    cursor Cursor
+    aborted bool
+}
+
+var _ ExprVisitor[any] = (*applier)(nil)
+
+func (applier *applier) apply(parent Expr, name string, expr Expr) Expr {
+    if applier.aborted || expr == nil {
+        return expr
+    }
+
+    saved := applier.cursor
+    applier.cursor = Cursor{parent, name, expr}
+
+    if (applier.pre == nil || applier.pre(&applier.cursor)) && applier.cursor.expr != nil {
+        rebuilt, _ := applier.cursor.expr.Accept(applier)
+        applier.cursor.expr = rebuilt.(Expr)
+
+        if applier.post != nil && !applier.post(&applier.cursor) {
+            applier.aborted = true
+        }
+    }
+
+    result := applier.cursor.expr
+    applier.cursor = saved
+    return result
+}
+
+func (applier *applier) VisitBinary(expr Binary) (any, error) {
+    parent := expr
+    expr.left = applier.apply(parent, "Left", expr.left)
+    expr.right = applier.apply(parent, "Right", expr.right)
+    return expr, nil
+}
+
+func (applier *applier) VisitGrouping(expr Grouping) (any, error) {
+    expr.expression = applier.apply(expr, "Expression", expr.expression)
+    return expr, nil
+}
+
+func (applier *applier) VisitLiteral(expr Literal) (any, error) {
+    return expr, nil
+}
+
+func (applier *applier) VisitUnary(expr Unary) (any, error) {
+    expr.right = applier.apply(expr, "Right", expr.right)
+    return expr, nil
+}
@@ -0,0 +1,141 @@
+package lox
+
+import (
+    "strings"
+    "testing"
+)
+
+// -(1 + 2) * 3
+var walkInput = Binary{
+    left: Unary{
+        operator: Token{tokenType: MINUS, lexeme: "-", literal: StringVal(""), line: 1},
+        right: Grouping{
+            expression: Binary{
+                left: Literal{value: NumberVal(1.0)},
+                operator: Token{tokenType: PLUS, lexeme: "+", literal: StringVal(""), line: 1},
+                right: Literal{value: NumberVal(2.0)},
+            },
+        },
+    },
+    operator: Token{tokenType: STAR, lexeme: "*", literal: StringVal(""), line: 1},
+    right: Literal{value: NumberVal(3.0)},
+}
+
+func TestInspect(t *testing.T) {
+    var visited []string
+    Inspect(walkInput, func(expr Expr) bool {
+        if expr == nil {
+            visited = append(visited, "end")
+            return false
+        }
+        visited = append(visited, expr.Print())
+        _, isGrouping := expr.(Grouping)
+        return !isGrouping
+    })
+
+    expected := []string{
+        "(* (- (group (+ 1 2))) 3)",
+        "(- (group (+ 1 2)))",
+        "(group (+ 1 2))",
+        "end",
+        "3",
+        "end",
+        "end",
+    }
+
+    if strings.Join(visited, "\n") != strings.Join(expected, "\n") {
+        t.Errorf("Incorrect visit order.\nresult  :\n%s\nexpected:\n%s\n", strings.Join(visited, "\n"), strings.Join(expected, "\n"))
+    }
+}
+
+func TestApply(t *testing.T) {
+    tests := []struct {
+        name string
+        pre ApplyFunc
+        post ApplyFunc
+        expected string
+    } {
+        {
+            name: "identity",
+            expected: "(* (- (group (+ 1 2))) 3)",
+        },
+        {
+            name: "replace literals in post",
+            post: func(cursor *Cursor) bool {
+                if literal, ok := cursor.Node().(Literal); ok {
+                    cursor.Replace(NewLiteral(NumberVal(literal.Value().AsNumber() * 10), literal.Line()))
+                }
+                return true
+            },
+            expected: "(* (- (group (+ 10 20))) 30)",
+        },
+        {
+            name: "unwrap groupings in pre",
+            pre: func(cursor *Cursor) bool {
+                if grouping, ok := cursor.Node().(Grouping); ok {
+                    cursor.Replace(grouping.Expression())
+                }
+                return true
+            },
+            expected: "(* (- (+ 1 2)) 3)",
+        },
+        {
+            name: "skip children of unary",
+            pre: func(cursor *Cursor) bool {
+                _, isUnary := cursor.Node().(Unary)
+                return !isUnary
+            },
+            post: func(cursor *Cursor) bool {
+                if _, ok := cursor.Node().(Literal); ok {
+                    cursor.Replace(NewLiteral(NumberVal(0), 1))
+                }
+                return true
+            },
+            expected: "(* (- (group (+ 1 2))) 0)",
+        },
+        {
+            name: "stop after first literal",
+            post: func(cursor *Cursor) bool {
+                if _, ok := cursor.Node().(Literal); ok {
+                    cursor.Replace(NewLiteral(NumberVal(0), 1))
+                    return false
+                }
+                return true
+            },
+            expected: "(* (- (group (+ 0 2))) 3)",
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            result := Apply(walkInput, test.pre, test.post)
+
+            if result.Print() != test.expected {
+                t.Errorf("Incorrect result.\nresult  :%s\nexpected:%s\n", result.Print(), test.expected)
+            }
+            if walkInput.Print() != "(* (- (group (+ 1 2))) 3)" {
+                t.Errorf("Apply modified its input: %s\n", walkInput.Print())
+            }
+        })
+    }
+}
+
+func TestApplyCursor(t *testing.T) {
+    var parents []string
+    Apply(walkInput, func(cursor *Cursor) bool {
+        if literal, ok := cursor.Node().(Literal); ok {
+            parents = append(parents, literal.Print() + " in " + cursor.Parent().Print() + "." + cursor.Name())
+        }
+        return true
+    }, nil)
+
+    expected := []string{
+        "1 in (+ 1 2).Left",
+        "2 in (+ 1 2).Right",
+        "3 in (* (- (group (+ 1 2))) 3).Right",
+    }
+
+    if strings.Join(parents, "\n") != strings.Join(expected, "\n") {
+        t.Errorf("Incorrect parents.\nresult  :\n%s\nexpected:\n%s\n", strings.Join(parents, "\n"), strings.Join(expected, "\n"))
+    }
+}
@@ -1,53 +1,149 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"glox/lox"
 	"log"
 	"os"
 )
 
+var (
+	engine    = flag.String("engine", "tree", "execution engine: tree, closure or vm")
+	traceExec = flag.Bool("trace-exec", false, "print the VM's value stack before each instruction (implies -engine=vm)")
+	optimize  = flag.Bool("O", false, "fold constant expressions before running or compiling")
+)
+
+// commands are the first arguments that select a subcommand rather than a
+// file to run.
+var commands = map[string]bool{
+    "debug": true,
+    "test-suite": true,
+    "compile": true,
+    "disasm": true,
+    "bench": true,
+}
+
 func main() {
     // hadError := false;
-    // args := os.Args
-    // runFile(args[1])
-    // types := []string{
-    //     "Binary   : Expr left, Token operator, Expr right",
-    //     "Grouping : Expr expression",
-    //     "Literal  : Object value",
-    //     "Unary    : Token operator, Expr right",
-    // }
-    // tools.DefineAst("./", "expr", types)
-
-
-    // fmt.Println(expr.Print())
-    fmt.Println("glox interpreter 1.0")
-    fmt.Println(">>>")
+    log.SetFlags(0)
+    flag.Parse()
+    args := flag.Args()
+
+    // The file to run may be followed by flags too, as in
+    // "glox file.lox -engine=vm". Anything else after it is a mistake.
+    if len(args) > 1 && !commands[args[0]] {
+        flag.CommandLine.Parse(args[1:])
+        if flag.NArg() > 0 {
+            fmt.Fprintln(os.Stderr, "usage: glox [flags] <file> [flags]")
+            os.Exit(64)
+        }
+        args = args[:1]
+    }
+
+    if *engine != "tree" && *engine != "closure" && *engine != "vm" {
+        fmt.Fprintf(os.Stderr, "unknown engine %q\n", *engine)
+        os.Exit(64)
+    }
+    if *traceExec {
+        *engine = "vm"
+    }
+
+    switch {
+    case len(args) == 0:
+        fmt.Println("glox interpreter 1.0")
+        fmt.Println(">>>")
+    case args[0] == "debug":
+        if len(args) != 2 {
+            fmt.Fprintln(os.Stderr, "usage: glox debug <file>")
+            os.Exit(64)
+        }
+        debugFile(args[1])
+    case args[0] == "test-suite":
+        testSuite(args[1:])
+    case args[0] == "compile":
+        compileFile(args[1:])
+    case args[0] == "disasm":
+        disassembleFile(args[1:])
+    case args[0] == "bench":
+        bench(args[1:])
+    default:
+        runFile(args[0])
+    }
 }
 
-func runFile(path string) {
-    fmt.Println("running file")
+func readSource(path string) string {
     bytes, err := os.ReadFile(path)
     if err != nil {
         log.Fatal(err)
     }
+    return string(bytes)
+}
+
+// runFile runs a source file, or a .loxc file produced by glox compile on
+// the VM.
+func runFile(path string) {
+    source := readSource(path)
+    if lox.IsLoxc([]byte(source)) {
+        runChunk(loadChunk(path))
+        return
+    }
 
-    run(string(bytes))
+    run(source)
 }
 
+// run scans, parses and evaluates source, printing its value. Parse errors
+// exit with status 65 and runtime errors with status 70.
 func run(source string) {
-    // tokens, err := lox.Scan(source)
-    // if err != nil {
-    //     log.Fatal("Error while scanning")
-    // }
+    expression := parseSource(source)
 
-    // for _, token := range tokens {
-    //     fmt.Println(token)
-    // }
+    if *engine == "vm" {
+        runChunk(compileExpression(expression))
+        return
+    }
 
-    // expression := lox.Parse(tokens)
-    // fmt.Println(expression)
+    var value any
+    var err error
+    if *engine == "closure" {
+        value, err = lox.CompileClosures(expression)()
+    } else {
+        value, err = lox.Interpret(expression)
+    }
+    if err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        os.Exit(70)
+    }
+    fmt.Println(lox.Stringify(value))
+}
+
+func runChunk(chunk *lox.Chunk) {
+    vm := lox.NewVM()
+    if *traceExec {
+        vm.Trace = os.Stdout
+    }
+
+    value, err := vm.Run(chunk)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        os.Exit(70)
+    }
+    fmt.Println(lox.Stringify(value))
 }
 
-func error(line int, where string, message string) {
-    fmt.Printf("[line %v] Error %v: %v\n", line, where, message);
+func parseSource(source string) lox.Expr {
+    tokens, err := lox.Scan(source)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        os.Exit(65)
+    }
+
+    expression, err := lox.Parse(tokens)
+    if err != nil {
+        os.Exit(65)
+    }
+
+    if *optimize {
+        expression = lox.Optimize(expression)
+    }
+    return expression
 }
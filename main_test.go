@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestFlagsAfterFile(t *testing.T) {
+	interpreter, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GLOX_RUN_MAIN", "1")
+
+	tests := []struct {
+		name     string
+		args     []string
+		output   string
+		exitCode int
+	}{
+		{
+			name:   "flag before the file",
+			args:   []string{"-trace-exec", "test/expressions/arithmetic.lox"},
+			output: "OP_CONSTANT",
+		},
+		{
+			name:   "flag after the file",
+			args:   []string{"test/expressions/arithmetic.lox", "-trace-exec"},
+			output: "OP_CONSTANT",
+		},
+		{
+			name:     "unknown engine after the file",
+			args:     []string{"test/expressions/arithmetic.lox", "-engine=bogus"},
+			exitCode: 64,
+		},
+		{
+			name:     "extra argument",
+			args:     []string{"test/expressions/arithmetic.lox", "extra"},
+			exitCode: 64,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := exec.Command(interpreter, test.args...).Output()
+
+			exitCode := 0
+			var exitError *exec.ExitError
+			if errors.As(err, &exitError) {
+				exitCode = exitError.ExitCode()
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if exitCode != test.exitCode {
+				t.Errorf("Incorrect exit code.\nresult  :%d\nexpected:%d\n", exitCode, test.exitCode)
+			}
+			if !strings.Contains(string(output), test.output) {
+				t.Errorf("Expected output containing %q, got %q.\n", test.output, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The annotation syntax follows the Crafting Interpreters test corpus.
+var (
+	expectedOutputPattern       = regexp.MustCompile(`// expect: ?(.*)`)
+	expectedErrorPattern        = regexp.MustCompile(`// (Error.*)`)
+	errorLinePattern            = regexp.MustCompile(`// \[line (\d+)\] (Error.*)`)
+	expectedRuntimeErrorPattern = regexp.MustCompile(`// expect runtime error: (.+)`)
+)
+
+type expectations struct {
+	output       []string
+	errors       []string
+	runtimeError string
+	exitCode     int
+}
+
+type suiteResult struct {
+	path     string
+	failures []string
+	elapsed  time.Duration
+}
+
+func testSuite(args []string) {
+	flags := flag.NewFlagSet("test-suite", flag.ExitOnError)
+	format := flags.String("format", "tap", "report format: tap or junit")
+	jobs := flags.Int("j", runtime.NumCPU(), "number of files to run in parallel")
+	testEngine := flags.String("engine", *engine, "execution engine to run the files with")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 || (*format != "tap" && *format != "junit") {
+		fmt.Fprintln(os.Stderr, "usage: glox test-suite [-format tap|junit] [-j n] [-engine tree|closure|vm] <dir>")
+		os.Exit(64)
+	}
+
+	paths, err := findTests(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	interpreter, err := os.Executable()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	results := make([]suiteResult, len(paths))
+	indexes := make(chan int)
+	var wait sync.WaitGroup
+	for i := 0; i < max(*jobs, 1); i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for index := range indexes {
+				results[index] = runTest(interpreter, *testEngine, paths[index])
+			}
+		}()
+	}
+	for index := range paths {
+		indexes <- index
+	}
+	close(indexes)
+	wait.Wait()
+
+	if *format == "junit" {
+		err = writeJUnit(results)
+	} else {
+		writeTAP(results)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	for _, result := range results {
+		if len(result.failures) > 0 {
+			os.Exit(1)
+		}
+	}
+}
+
+func findTests(dir string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && filepath.Ext(path) == ".lox" {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	slices.Sort(paths)
+	return paths, err
+}
+
+func parseExpectations(source string) expectations {
+	var expected expectations
+
+	for i, line := range strings.Split(source, "\n") {
+		lineNumber := i + 1
+
+		if match := expectedOutputPattern.FindStringSubmatch(line); match != nil {
+			expected.output = append(expected.output, match[1])
+		} else if match := expectedRuntimeErrorPattern.FindStringSubmatch(line); match != nil {
+			expected.runtimeError = match[1]
+			expected.exitCode = 70
+		} else if match := errorLinePattern.FindStringSubmatch(line); match != nil {
+			expected.errors = append(expected.errors, fmt.Sprintf("[line %s] %s", match[1], match[2]))
+			expected.exitCode = 65
+		} else if match := expectedErrorPattern.FindStringSubmatch(line); match != nil {
+			expected.errors = append(expected.errors, fmt.Sprintf("[line %d] %s", lineNumber, match[1]))
+			expected.exitCode = 65
+		}
+	}
+
+	return expected
+}
+
+func runTest(interpreter string, engine string, path string) suiteResult {
+	result := suiteResult{path: path}
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		result.failures = append(result.failures, err.Error())
+		return result
+	}
+	expected := parseExpectations(string(source))
+
+	var stdout, stderr bytes.Buffer
+	command := exec.Command(interpreter, "-engine="+engine, path)
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+
+	start := time.Now()
+	err = command.Run()
+	result.elapsed = time.Since(start)
+
+	exitCode := 0
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		exitCode = exitError.ExitCode()
+	} else if err != nil {
+		result.failures = append(result.failures, err.Error())
+		return result
+	}
+
+	result.failures = append(result.failures, validateErrors(expected, lines(stderr.String()))...)
+	result.failures = append(result.failures, validateOutput(expected, lines(stdout.String()))...)
+	if exitCode != expected.exitCode {
+		result.failures = append(result.failures, fmt.Sprintf("Expected return code %d and got %d.", expected.exitCode, exitCode))
+	}
+
+	return result
+}
+
+func validateErrors(expected expectations, errorLines []string) []string {
+	var failures []string
+
+	if expected.runtimeError != "" {
+		if len(errorLines) == 0 {
+			return []string{fmt.Sprintf("Expected runtime error %q and got none.", expected.runtimeError)}
+		}
+		if errorLines[0] != expected.runtimeError {
+			return []string{fmt.Sprintf("Expected runtime error %q and got %q.", expected.runtimeError, errorLines[0])}
+		}
+		return nil
+	}
+
+	remaining := slices.Clone(expected.errors)
+	for _, line := range errorLines {
+		if index := slices.Index(remaining, line); index >= 0 {
+			remaining = slices.Delete(remaining, index, index+1)
+		} else {
+			failures = append(failures, fmt.Sprintf("Unexpected error: %q", line))
+		}
+	}
+	for _, missing := range remaining {
+		failures = append(failures, fmt.Sprintf("Missing expected error: %q", missing))
+	}
+
+	return failures
+}
+
+func validateOutput(expected expectations, outputLines []string) []string {
+	var failures []string
+
+	for i, line := range outputLines {
+		if i >= len(expected.output) {
+			failures = append(failures, fmt.Sprintf("Got output %q when none was expected.", line))
+		} else if line != expected.output[i] {
+			failures = append(failures, fmt.Sprintf("Expected output %q and got %q.", expected.output[i], line))
+		}
+	}
+	for _, missing := range expected.output[min(len(outputLines), len(expected.output)):] {
+		failures = append(failures, fmt.Sprintf("Missing expected output %q.", missing))
+	}
+
+	return failures
+}
+
+func lines(text string) []string {
+	var result []string
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		result = append(result, scanner.Text())
+	}
+	return result
+}
+
+func writeTAP(results []suiteResult) {
+	fmt.Println("TAP version 13")
+	fmt.Printf("1..%d\n", len(results))
+
+	for i, result := range results {
+		if len(result.failures) == 0 {
+			fmt.Printf("ok %d - %s\n", i+1, result.path)
+			continue
+		}
+
+		fmt.Printf("not ok %d - %s\n", i+1, result.path)
+		fmt.Println("  ---")
+		fmt.Println("  failures:")
+		for _, failure := range result.failures {
+			fmt.Printf("    - %s\n", strconv.Quote(failure))
+		}
+		fmt.Println("  ...")
+	}
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitTestCase struct {
+	Name    string        `xml:"name,attr"`
+	Time    float64       `xml:"time,attr"`
+	Failure *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+func writeJUnit(results []suiteResult) error {
+	suite := junitTestSuite{Name: "glox", Tests: len(results)}
+
+	for _, result := range results {
+		testCase := junitTestCase{Name: result.path, Time: result.elapsed.Seconds()}
+		if len(result.failures) > 0 {
+			suite.Failures++
+			testCase.Failure = &junitFailure{
+				Message: result.failures[0],
+				Text:    strings.Join(result.failures, "\n"),
+			}
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	output, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Print(xml.Header)
+	fmt.Println(string(output))
+	return nil
+}
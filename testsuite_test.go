@@ -0,0 +1,175 @@
+package main
+
+import (
+	"os"
+	"slices"
+	"testing"
+)
+
+// TestMain runs glox itself when the test binary is started by runTest, so
+// that the fixtures can be run without building glox first.
+func TestMain(m *testing.M) {
+	if os.Getenv("GLOX_RUN_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestParseExpectations(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected expectations
+	}{
+		{
+			name:     "output",
+			source:   "1 + 2 // expect: 3\n// expect:\n",
+			expected: expectations{output: []string{"3", ""}},
+		},
+		{
+			name:   "errors",
+			source: "1 +\n// [line 1] Error at end: Expect expression\n+ // Error at '+': Expect expression\n",
+			expected: expectations{
+				errors:   []string{"[line 1] Error at end: Expect expression", "[line 3] Error at '+': Expect expression"},
+				exitCode: 65,
+			},
+		},
+		{
+			name:     "runtime error",
+			source:   "-\"a\" // expect runtime error: not a number\n",
+			expected: expectations{runtimeError: "not a number", exitCode: 70},
+		},
+		{
+			name:   "none",
+			source: "1 // a comment\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := parseExpectations(test.source)
+			if !slices.Equal(result.output, test.expected.output) ||
+				!slices.Equal(result.errors, test.expected.errors) ||
+				result.runtimeError != test.expected.runtimeError ||
+				result.exitCode != test.expected.exitCode {
+				t.Errorf("Incorrect result.\nresult  :%+v\nexpected:%+v\n", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expected   expectations
+		errorLines []string
+		failures   []string
+	}{
+		{
+			name:       "expected errors",
+			expected:   expectations{errors: []string{"[line 1] Error: a", "[line 2] Error: b"}},
+			errorLines: []string{"[line 2] Error: b", "[line 1] Error: a"},
+		},
+		{
+			name:       "unexpected and missing errors",
+			expected:   expectations{errors: []string{"[line 1] Error: a"}},
+			errorLines: []string{"[line 1] Error: b"},
+			failures:   []string{`Unexpected error: "[line 1] Error: b"`, `Missing expected error: "[line 1] Error: a"`},
+		},
+		{
+			name:       "runtime error",
+			expected:   expectations{runtimeError: "not a number"},
+			errorLines: []string{"not a number"},
+		},
+		{
+			name:     "missing runtime error",
+			expected: expectations{runtimeError: "not a number"},
+			failures: []string{`Expected runtime error "not a number" and got none.`},
+		},
+		{
+			name:       "wrong runtime error",
+			expected:   expectations{runtimeError: "not a number"},
+			errorLines: []string{"division by zero", "not a number"},
+			failures:   []string{`Expected runtime error "not a number" and got "division by zero".`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := validateErrors(test.expected, test.errorLines)
+			if !slices.Equal(result, test.failures) {
+				t.Errorf("Incorrect result.\nresult  :%q\nexpected:%q\n", result, test.failures)
+			}
+		})
+	}
+}
+
+func TestValidateOutput(t *testing.T) {
+	tests := []struct {
+		name        string
+		expected    []string
+		outputLines []string
+		failures    []string
+	}{
+		{
+			name:        "expected output",
+			expected:    []string{"1", "2"},
+			outputLines: []string{"1", "2"},
+		},
+		{
+			name:        "wrong output",
+			expected:    []string{"1", "2"},
+			outputLines: []string{"1", "3"},
+			failures:    []string{`Expected output "2" and got "3".`},
+		},
+		{
+			name:        "extra output",
+			expected:    []string{"1"},
+			outputLines: []string{"1", "2"},
+			failures:    []string{`Got output "2" when none was expected.`},
+		},
+		{
+			name:        "missing output",
+			expected:    []string{"1", "2", "3"},
+			outputLines: []string{"1"},
+			failures:    []string{`Missing expected output "2".`, `Missing expected output "3".`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := validateOutput(expectations{output: test.expected}, test.outputLines)
+			if !slices.Equal(result, test.failures) {
+				t.Errorf("Incorrect result.\nresult  :%q\nexpected:%q\n", result, test.failures)
+			}
+		})
+	}
+}
+
+func TestSuiteFixtures(t *testing.T) {
+	paths, err := findTests("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("No test files found.")
+	}
+
+	interpreter, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GLOX_RUN_MAIN", "1")
+
+	for _, engine := range []string{"tree", "closure", "vm"} {
+		for _, path := range paths {
+			t.Run(engine+"/"+path, func(t *testing.T) {
+				result := runTest(interpreter, engine, path)
+				for _, failure := range result.failures {
+					t.Error(failure)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// Generateast writes the syntax tree node types of package lox from a grammar
+// description. It is run through go generate:
+//
+//	//go:generate go run ../tools/generateast -grammar ast.grammar
+//
+// See lox/ast.grammar for the grammar format.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type field struct {
+	name     string
+	typeName string
+}
+
+type node struct {
+	name   string
+	fields []field
+}
+
+type family struct {
+	base  string
+	nodes []node
+}
+
+func main() {
+	grammarPath := flag.String("grammar", "ast.grammar", "grammar description file")
+	outputDir := flag.String("output", ".", "directory to write the generated files to")
+	packageName := flag.String("package", "lox", "package name of the generated files")
+	flag.Parse()
+
+	if err := run(*grammarPath, *outputDir, *packageName); err != nil {
+		fmt.Fprintf(os.Stderr, "generateast: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(grammarPath string, outputDir string, packageName string) error {
+	grammar, err := os.ReadFile(grammarPath)
+	if err != nil {
+		return err
+	}
+
+	families, err := parseGrammar(string(grammar), grammarPath)
+	if err != nil {
+		return err
+	}
+
+	for _, family := range families {
+		source, err := generate(family, packageName, filepath.Base(grammarPath))
+		if err != nil {
+			return fmt.Errorf("generating %s: %w", family.base, err)
+		}
+
+		path := filepath.Join(outputDir, strings.ToLower(family.base)+".go")
+		if err := os.WriteFile(path, source, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseGrammar(grammar string, path string) ([]family, error) {
+	var families []family
+
+	scanner := bufio.NewScanner(strings.NewReader(grammar))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if base, found := strings.CutPrefix(line, "base "); found {
+			families = append(families, family{base: strings.TrimSpace(base)})
+			continue
+		}
+
+		if len(families) == 0 {
+			return nil, fmt.Errorf("%s:%d: node declared before any base", path, lineNumber)
+		}
+
+		node, err := parseNode(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+
+		current := &families[len(families)-1]
+		current.nodes = append(current.nodes, node)
+	}
+
+	return families, scanner.Err()
+}
+
+func parseNode(line string) (node, error) {
+	name, rest, found := strings.Cut(line, ":")
+	if !found {
+		return node{}, fmt.Errorf("expected \"Node : fields\", got %q", line)
+	}
+
+	result := node{name: strings.TrimSpace(name)}
+
+	for _, declaration := range strings.Split(rest, ",") {
+		parts := strings.Fields(declaration)
+		if len(parts) != 2 {
+			return node{}, fmt.Errorf("expected \"Type name\", got %q", strings.TrimSpace(declaration))
+		}
+		result.fields = append(result.fields, field{name: parts[1], typeName: parts[0]})
+	}
+
+	return result, nil
+}
+
+func generate(family family, packageName string, grammarName string) ([]byte, error) {
+	var output bytes.Buffer
+
+	fmt.Fprintf(&output, "// Code generated by generateast from %s; DO NOT EDIT.\n\n", grammarName)
+	fmt.Fprintf(&output, "package %s\n\n", packageName)
+	fmt.Fprintf(&output, "import \"fmt\"\n\n")
+
+	recv := receiver(family)
+
+	fmt.Fprintf(&output, "type %s interface {\n", family.base)
+	fmt.Fprintf(&output, "Print() string\n")
+	fmt.Fprintf(&output, "Line() int\n")
+	fmt.Fprintf(&output, "Accept(visitor %sVisitor[any]) (any, error)\n", family.base)
+	fmt.Fprintf(&output, "}\n\n")
+
+	fmt.Fprintf(&output, "// %sVisitor is implemented by passes over %s trees, with one method\n", family.base, family.base)
+	fmt.Fprintf(&output, "// per node type. Asserting that a pass implements it catches node types\n")
+	fmt.Fprintf(&output, "// the pass forgets to handle at compile time.\n")
+	fmt.Fprintf(&output, "type %sVisitor[R any] interface {\n", family.base)
+	for _, node := range family.nodes {
+		fmt.Fprintf(&output, "Visit%s(%s %s) (R, error)\n", node.name, recv, node.name)
+	}
+	fmt.Fprintf(&output, "}\n\n")
+
+	fmt.Fprintf(&output, "// Visit%s dispatches %s to the matching method of visitor. Go methods\n", family.base, recv)
+	fmt.Fprintf(&output, "// cannot take type parameters, so passes returning something other than any\n")
+	fmt.Fprintf(&output, "// go through Visit%s instead of Accept.\n", family.base)
+	fmt.Fprintf(&output, "func Visit%s[R any](%s %s, visitor %sVisitor[R]) (R, error) {\n", family.base, recv, family.base, family.base)
+	fmt.Fprintf(&output, "switch %s := %s.(type) {\n", recv, recv)
+	for _, node := range family.nodes {
+		fmt.Fprintf(&output, "case %s:\n", node.name)
+		fmt.Fprintf(&output, "return visitor.Visit%s(%s)\n", node.name, recv)
+	}
+	fmt.Fprintf(&output, "}\n")
+	fmt.Fprintf(&output, "var zero R\n")
+	fmt.Fprintf(&output, "return zero, fmt.Errorf(\"unknown %s %%T\", %s)\n", recv, recv)
+	fmt.Fprintf(&output, "}\n\n")
+
+	for _, node := range family.nodes {
+		generateNode(&output, family, node)
+	}
+
+	return format.Source(output.Bytes())
+}
+
+func generateNode(output *bytes.Buffer, family family, node node) {
+	recv := receiver(family)
+
+	fmt.Fprintf(output, "type %s struct {\n", node.name)
+	for _, field := range node.fields {
+		fmt.Fprintf(output, "%s %s\n", field.name, field.typeName)
+	}
+	fmt.Fprintf(output, "line int\n")
+	fmt.Fprintf(output, "}\n\n")
+
+	var parameters, arguments []string
+	for _, field := range node.fields {
+		parameters = append(parameters, field.name+" "+field.typeName)
+		arguments = append(arguments, field.name)
+	}
+	fmt.Fprintf(output, "func New%s(%s, line int) %s {\n", node.name, strings.Join(parameters, ", "), node.name)
+	fmt.Fprintf(output, "return %s{%s, line}\n", node.name, strings.Join(arguments, ", "))
+	fmt.Fprintf(output, "}\n\n")
+
+	for _, field := range node.fields {
+		fmt.Fprintf(output, "func (%s %s) %s() %s {\n", recv, node.name, exported(field.name), field.typeName)
+		fmt.Fprintf(output, "return %s.%s\n", recv, field.name)
+		fmt.Fprintf(output, "}\n\n")
+	}
+
+	fmt.Fprintf(output, "func (%s %s) Print() string {\n", recv, node.name)
+	fmt.Fprintf(output, "return print%s(%s)\n", family.base, recv)
+	fmt.Fprintf(output, "}\n\n")
+
+	fmt.Fprintf(output, "func (%s %s) Line() int {\n", recv, node.name)
+	fmt.Fprintf(output, "return %s.line\n", recv)
+	fmt.Fprintf(output, "}\n\n")
+
+	fmt.Fprintf(output, "func (%s %s) Accept(visitor %sVisitor[any]) (any, error) {\n", recv, node.name, family.base)
+	fmt.Fprintf(output, "return visitor.Visit%s(%s)\n", node.name, recv)
+	fmt.Fprintf(output, "}\n\n")
+}
+
+func exported(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+func receiver(family family) string {
+	return strings.ToLower(family.base)
+}